@@ -3,110 +3,362 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"marketflow/internal/adapters/cache"
+	"marketflow/internal/adapters/exchange"
 	"marketflow/internal/adapters/storage"
 	"marketflow/internal/adapters/web"
+	"marketflow/internal/alerts"
+	"marketflow/internal/arbitrage"
 	"marketflow/internal/config"
 	"marketflow/internal/domain"
+	"marketflow/internal/feeds"
+	"marketflow/internal/stream"
 	"marketflow/internal/worker"
 	"marketflow/pkg/logger"
 
 	_ "github.com/lib/pq"
 )
 
+const usage = `Usage:
+  marketflow [command] [options]
+  marketflow --help
+
+Commands:
+  serve          Run ingestion and the HTTP API (default)
+  migrate        Apply the database schema and exit
+  check-config   Validate the config file and exit
+
+Settings are read from the config file, then MARKETFLOW_<SECTION>_<KEY>
+environment variables (e.g. MARKETFLOW_POSTGRES_HOST), then the options below.
+
+Options:
+  --port N            Port number, overrides server.port (default 8080)
+  --config PATH       Config file (default config.json)
+  --mode MODE         Start mode, live or test; overrides the config file
+  --log-level LEVEL   debug, info, warn or error; overrides log_level
+  --help              Show this help
+
+While serving, SIGHUP reloads the config file, as does saving it when
+reload.watch is on. Settings that cannot change in place are reported by
+GET /admin/config and take effect on the next restart.
+`
+
+type cliOptions struct {
+	command    string
+	port       int // 0 when not given
+	configPath string
+	mode       string
+	logLevel   string // empty when not given
+}
+
+// parseArgs accepts the command before or after the options. It returns
+// flag.ErrHelp when help was asked for.
+func parseArgs(args []string) (cliOptions, error) {
+	opts := cliOptions{command: "serve"}
+
+	fs := flag.NewFlagSet("marketflow", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.IntVar(&opts.port, "port", 0, "")
+	fs.StringVar(&opts.configPath, "config", "config.json", "")
+	fs.StringVar(&opts.mode, "mode", "", "")
+	fs.StringVar(&opts.logLevel, "log-level", "", "")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		opts.command = fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return opts, err
+		}
+		if fs.NArg() > 0 {
+			return opts, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+		}
+	}
+
+	switch opts.command {
+	case "serve", "migrate", "check-config":
+	default:
+		return opts, fmt.Errorf("unknown command %q", opts.command)
+	}
+	portSet := false
+	fs.Visit(func(f *flag.Flag) { portSet = portSet || f.Name == "port" })
+	if portSet && (opts.port < 1 || opts.port > 65535) {
+		return opts, fmt.Errorf("invalid port %d, must be between 1 and 65535", opts.port)
+	}
+	if opts.mode != "" && opts.mode != "live" && opts.mode != "test" {
+		return opts, fmt.Errorf("invalid mode %q, must be live or test", opts.mode)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.logLevel)); opts.logLevel != "" && err != nil {
+		return opts, fmt.Errorf("invalid log level %q, must be debug, info, warn or error", opts.logLevel)
+	}
+	return opts, nil
+}
+
 func main() {
-	logger, cleanup := logger.SetupLogger()
-	defer cleanup()
+	opts, err := parseArgs(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Print(usage)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "marketflow: %v\nRun 'marketflow --help' for usage.\n", err)
+		os.Exit(2)
+	}
 
-	cfg, err := config.LoadConfig("config.json")
+	cfg, err := loadConfig(opts)
+	if opts.command == "check-config" {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", opts.configPath, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", opts.configPath)
+		return
+	}
 	if err != nil {
-		logger.Error("Failed to load config", "error", err)
+		fmt.Fprintf(os.Stderr, "marketflow: %s: %v\n", opts.configPath, err)
 		os.Exit(1)
 	}
 
-	logger.Info("Starting application", "mode", cfg.Mode)
+	var level slog.LevelVar
+	level.Set(cfg.Level())
+	logger, cleanup := logger.SetupLogger(&level)
+	defer cleanup()
+
+	switch opts.command {
+	case "migrate":
+		runMigrate(cfg, logger)
+	default:
+		runServe(opts, cfg, logger, &level)
+	}
+}
+
+// loadConfig layers the command-line options over the config file and
+// environment, then validates the result.
+func loadConfig(opts cliOptions) (*config.Config, error) {
+	cfg, err := config.LoadConfig(opts.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if opts.mode != "" {
+		cfg.Mode = opts.mode
+	}
+	if opts.port != 0 {
+		cfg.Server.Port = opts.port
+	}
+	if opts.logLevel != "" {
+		cfg.LogLevel = opts.logLevel
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
 
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Postgres.Host,
-		cfg.Postgres.Port,
-		cfg.Postgres.User,
-		cfg.Postgres.Password,
-		cfg.Postgres.DBName,
-		cfg.Postgres.SSLMode,
-	)
-
-	db, err := sql.Open("postgres", dsn)
+func openDB(cfg config.PostgresCfg, logger *slog.Logger) *sql.DB {
+	db, err := sql.Open("postgres", cfg.DSN())
 	if err != nil {
 		logger.Error("Failed to connect to PostgreSQL", "error", err)
 		os.Exit(1)
 	}
-	defer db.Close()
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(10)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLife.Value())
 
 	// Wait for PostgreSQL
-	for i := 0; i < 10; i++ {
+	for i := 0; i < cfg.ConnectRetries; i++ {
 		err = db.Ping()
 		if err == nil {
 			break
 		}
 		logger.Info("Waiting for PostgreSQL...", "attempt", i+1)
-		time.Sleep(2 * time.Second)
+		time.Sleep(cfg.RetryInterval.Value())
 	}
 	if err != nil {
 		logger.Error("PostgreSQL is not responding", "error", err)
 		os.Exit(1)
 	}
 
+	return db
+}
+
+func runMigrate(cfg *config.Config, logger *slog.Logger) {
+	db := openDB(cfg.Postgres, logger)
+	defer db.Close()
+
 	if err := storage.InitDB(db); err != nil {
 		logger.Error("Failed to initialize database", "error", err)
 		os.Exit(1)
 	}
+	logger.Info("Database schema applied")
+}
 
-	redisAddr := fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)
+func runServe(opts cliOptions, cfg *config.Config, logger *slog.Logger, level *slog.LevelVar) {
+	logger.Info("Starting application", "mode", cfg.Mode)
 
-	poolSize := 50
-	if ps := os.Getenv("REDIS_POOL_SIZE"); ps != "" {
-		if n, err := strconv.Atoi(ps); err == nil {
-			poolSize = n
-		}
+	db := openDB(cfg.Postgres, logger)
+	defer db.Close()
+
+	if err := storage.InitDB(db); err != nil {
+		logger.Error("Failed to initialize database", "error", err)
+		os.Exit(1)
 	}
 
-	redisClient, err := cache.NewRedisClient(redisAddr, logger, poolSize) // Increased pool size
+	redisOpts := cache.Options{
+		Username:      cfg.Redis.Username,
+		Password:      cfg.Redis.Password,
+		DB:            cfg.Redis.DB,
+		ClientName:    cfg.Redis.ClientName,
+		TLS:           cfg.Redis.TLS,
+		TLSCAFile:     cfg.Redis.TLSCAFile,
+		TLSServerName: cfg.Redis.TLSServerName,
+		TLSSkipVerify: cfg.Redis.TLSSkipVerify,
+
+		SentinelAddrs:    cfg.Redis.SentinelAddrs,
+		SentinelPassword: cfg.Redis.SentinelPassword,
+		MasterName:       cfg.Redis.MasterName,
+
+		MinIdle:          cfg.Redis.MinIdle,
+		MaxIdle:          cfg.Redis.MaxIdle,
+		BreakerThreshold: cfg.Redis.BreakerThreshold,
+		BreakerCooldown:  cfg.Redis.BreakerCooldown.Value(),
+		PriceRetention:   cfg.Redis.PriceRetention.Value(),
+	}
+
+	// Redis being down is not fatal: the client dials lazily and recovers on its own
+	redisClient, err := cache.NewRedisClient(cfg.Redis.Address(), logger, cfg.Redis.PoolSize, redisOpts)
 	if err != nil {
-		logger.Error("Failed to connect to Redis", "error", err)
+		logger.Error("Invalid Redis configuration", "error", err)
 		os.Exit(1)
 	}
+	defer redisClient.Close()
 
-	toPG := make(chan domain.PriceUpdate, 20000) // Increased buffer size
+	toPG := make(chan domain.PriceUpdate, cfg.Ingestion.PostgresBuffer)
 	modeManager := domain.NewModeManager()
+	if cfg.Mode == "test" {
+		modeManager.SetMode(context.Background(), domain.ModeTest)
+	}
+	hub := stream.NewHub(cfg.Stream.HistorySize)
+	modeManager.OnChange(func(mode domain.Mode) {
+		hub.Publish("mode", "", "", mode.String())
+	})
+
+	windows := make([]time.Duration, len(cfg.Stats.Windows))
+	for i, w := range cfg.Stats.Windows {
+		windows[i] = w.Value()
+	}
+	statsEngine := worker.NewStatsEngine(windows)
+
+	monitor := feeds.NewMonitor(feedsConfig(cfg))
+	for _, feed := range expectedFeeds(cfg) {
+		monitor.Expect(feed[0], feed[1])
+	}
+
+	pipeline := worker.NewPipeline()
+	pipeline.Track("to_postgres", func() int { return len(toPG) }, cap(toPG))
 
-	go worker.StartIngestion(logger, redisClient, db, toPG, modeManager)
+	ingestion := worker.StartIngestion(logger, redisClient, db, toPG, modeManager, hub, statsEngine, monitor, pipeline, ingestionConfig(cfg), validationConfig(cfg))
 
-	go worker.StartIngestion(logger, redisClient, db, toPG, modeManager)
+	detector := arbitrage.NewDetector(arbitrageConfig(cfg), db, hub, logger)
+	analyticsCtx, stopAnalytics := context.WithCancel(context.Background())
+	defer stopAnalytics()
+	go detector.Run(analyticsCtx)
+
+	alertEngine := alerts.NewEngine(db, hub, alerts.NewDispatcher(dispatcherConfig(cfg), logger), logger)
+	if err := alertEngine.Load(context.Background()); err != nil {
+		logger.Error("Failed to load alert rules", "error", err)
+		os.Exit(1)
+	}
+	alertEngine.SetExchanges(exchangeNames(cfg))
+	go alertEngine.Run(analyticsCtx)
+
+	// Only settings listed as reloadable in the config package reach here
+	// without Restart set; the rest wait for the next start.
+	applyReload := func(old, new *config.Config, changes []config.Change) {
+		changed := func(prefixes ...string) bool {
+			for _, ch := range changes {
+				for _, p := range prefixes {
+					if !ch.Restart && strings.HasPrefix(ch.Path, p) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+
+		if changed("log_level") {
+			level.Set(new.Level())
+		}
+		if changed("mode") {
+			mode := domain.ModeLive
+			if new.Mode == "test" {
+				mode = domain.ModeTest
+			}
+			modeManager.SetMode(context.Background(), mode)
+		}
+		if changed("redis.price_retention") {
+			redisClient.SetPriceRetention(new.Redis.PriceRetention.Value())
+		}
+		if changed("arbitrage.") {
+			detector.SetConfig(arbitrageConfig(new))
+		}
+		if changed("feeds.", "exchanges") {
+			monitor.SetConfig(feedsConfig(new))
+			keep := make(map[[2]string]bool)
+			for _, feed := range expectedFeeds(new) {
+				keep[feed] = true
+				monitor.Expect(feed[0], feed[1])
+			}
+			for _, feed := range expectedFeeds(old) {
+				if !keep[feed] {
+					monitor.Forget(feed[0], feed[1])
+				}
+			}
+		}
+		if changed("exchanges") {
+			alertEngine.SetExchanges(exchangeNames(new))
+		}
+		if changed("exchanges", "ingestion.", "validation.") {
+			ingestion.Reconfigure(ingestionConfig(new), validationConfig(new))
+		}
+	}
+	reloader := config.NewReloader(opts.configPath, cfg, func() (*config.Config, error) { return loadConfig(opts) }, applyReload, logger)
+	go reloader.Watch(analyticsCtx)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloader.Reload("sighup")
+		}
+	}()
 
-	router := web.NewRouter(db, redisClient, modeManager)
+	router := web.NewRouter(db, redisClient, modeManager, hub, detector, alertEngine, statsEngine, monitor, pipeline, reloader, healthConfig(cfg))
 	server := &http.Server{
-		Addr:         ":8080",
-		Handler:      router,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:         ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:      web.InstrumentHandler(router),
+		ReadTimeout:  cfg.Server.ReadTimeout.Value(),
+		WriteTimeout: cfg.Server.WriteTimeout.Value(),
+		IdleTimeout:  cfg.Server.IdleTimeout.Value(),
 	}
 
 	go func() {
-		logger.Info("Starting server on :8080")
+		logger.Info("Starting server", "addr", server.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Error("Server error", "error", err)
 			os.Exit(1)
@@ -120,7 +372,7 @@ func main() {
 	close(toPG)
 
 	logger.Info("Shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Value())
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
@@ -128,3 +380,94 @@ func main() {
 	}
 	logger.Info("Server stopped")
 }
+
+func validationConfig(cfg *config.Config) worker.ValidationConfig {
+	return worker.ValidationConfig{
+		Enabled:         cfg.Validation.Enabled,
+		Symbols:         cfg.Validation.Symbols,
+		Window:          cfg.Validation.Window,
+		MinSamples:      cfg.Validation.MinSamples,
+		MaxDeviationPct: cfg.Validation.MaxDeviationPct,
+		MaxStdDevs:      cfg.Validation.MaxStdDevs,
+		ConsensusPct:    cfg.Validation.ConsensusPct,
+		ConsensusAge:    cfg.Validation.ConsensusAge.Value(),
+	}
+}
+
+func arbitrageConfig(cfg *config.Config) arbitrage.Config {
+	return arbitrage.Config{
+		ThresholdBps:  cfg.Arbitrage.ThresholdBps,
+		FeesBps:       cfg.Arbitrage.FeesBps,
+		DefaultFeeBps: cfg.Arbitrage.DefaultFeeBps,
+		MinDuration:   cfg.Arbitrage.MinDuration.Value(),
+		MaxQuoteAge:   cfg.Arbitrage.MaxQuoteAge.Value(),
+	}
+}
+
+func feedsConfig(cfg *config.Config) feeds.Config {
+	return feeds.Config{
+		StaleAfter:    cfg.Feeds.StaleAfter.Value(),
+		GapAfter:      cfg.Feeds.GapAfter.Value(),
+		MaxErrorRatio: cfg.Feeds.MaxErrorRatio,
+	}
+}
+
+func dispatcherConfig(cfg *config.Config) alerts.DispatcherConfig {
+	return alerts.DispatcherConfig{
+		Workers:    cfg.Alerts.WebhookWorkers,
+		QueueSize:  cfg.Alerts.WebhookQueue,
+		Timeout:    cfg.Alerts.WebhookTimeout.Value(),
+		MaxRetries: cfg.Alerts.WebhookRetries,
+		Backoff:    cfg.Alerts.WebhookBackoff.Value(),
+	}
+}
+
+func healthConfig(cfg *config.Config) web.HealthConfig {
+	return web.HealthConfig{
+		CacheTTL:        cfg.Health.CacheTTL.Value(),
+		SlowPostgres:    cfg.Health.SlowPostgres.Value(),
+		QueueHighWater:  cfg.Health.QueueHighWater,
+		FlushStaleAfter: cfg.Health.FlushStaleAfter.Value(),
+	}
+}
+
+func exchangeNames(cfg *config.Config) []string {
+	names := make([]string, len(cfg.Exchanges))
+	for i, ex := range cfg.Exchanges {
+		names[i] = ex.Name
+	}
+	return names
+}
+
+// expectedFeeds lists the exchange/symbol pairs the feed monitor should
+// report on even before their first tick.
+func expectedFeeds(cfg *config.Config) [][2]string {
+	var out [][2]string
+	for _, src := range ingestionConfig(cfg).Sources {
+		for _, symbol := range cfg.Feeds.Symbols {
+			out = append(out, [2]string{src.Name, symbol})
+		}
+	}
+	return out
+}
+
+func ingestionConfig(cfg *config.Config) worker.IngestionConfig {
+	in := cfg.Ingestion
+	out := worker.IngestionConfig{
+		FanInBuffer:       in.FanInBuffer,
+		RedisBuffer:       in.RedisBuffer,
+		QuarantineBuffer:  in.QuarantineBuffer,
+		RedisWorkers:      in.RedisWorkers,
+		PoolWorkers:       in.PoolWorkers,
+		AggregateEvery:    in.AggregateEvery,
+		AggregateInterval: in.AggregateInterval.Value(),
+		RedisTimeout:      cfg.Redis.CommandTimeout.Value(),
+		BatchSize:         in.BatchSize,
+		FlushInterval:     in.FlushInterval.Value(),
+		ReconnectDelay:    in.ReconnectDelay.Value(),
+	}
+	for _, ex := range cfg.Exchanges {
+		out.Sources = append(out.Sources, exchange.Source{Name: ex.Name, Addr: ex.Addr})
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without touching the network while Redis is
+// considered down.
+var ErrCircuitOpen = errors.New("redis circuit open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitClosed:
+		return "closed"
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuitBreaker opens after threshold consecutive failures and lets a single
+// probe through once cooldown has elapsed.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     circuitState
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	openedAt  time.Time
+	probeAt   time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitClosed:
+		return true
+	case circuitOpen:
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		cb.state = circuitHalfOpen
+		cb.probeAt = time.Now()
+		return true
+	default:
+		// A probe is already in flight; give up on it if it never reported back
+		if time.Since(cb.probeAt) < cb.cooldown {
+			return false
+		}
+		cb.probeAt = time.Now()
+		return true
+	}
+}
+
+// success reports whether the breaker was not closed before, i.e. Redis recovered.
+func (cb *circuitBreaker) success() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	recovered := cb.state != circuitClosed
+	cb.state = circuitClosed
+	cb.failures = 0
+	return recovered
+}
+
+// failure reports whether this failure opened the breaker.
+func (cb *circuitBreaker) failure() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	if cb.state == circuitHalfOpen || (cb.state == circuitClosed && cb.failures >= cb.threshold) {
+		cb.state = circuitOpen
+		cb.openedAt = time.Now()
+		return true
+	}
+	if cb.state == circuitOpen {
+		cb.openedAt = time.Now()
+	}
+	return false
+}
+
+func (cb *circuitBreaker) current() circuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
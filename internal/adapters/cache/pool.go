@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"marketflow/pkg/metrics"
+)
+
+var errPoolTimeout = errors.New("connection pool timeout")
+
+var (
+	poolWaitSeconds = metrics.NewHistogram("marketflow_redis_pool_wait_seconds", "Time spent waiting for a free pool slot.", nil)
+	poolTimeouts    = metrics.NewCounter("marketflow_redis_pool_timeouts_total", "Commands that gave up waiting for a pool slot.")
+)
+
+// getConn reserves a slot and hands out an idle connection, dialing a new one
+// when none is idle.
+func (rc *RedisClient) getConn() (net.Conn, error) {
+	start := time.Now()
+	select {
+	case rc.slots <- struct{}{}:
+		poolWaitSeconds.ObserveSince(start)
+	case <-time.After(500 * time.Millisecond):
+		poolTimeouts.Inc()
+		return nil, errPoolTimeout
+	}
+
+	select {
+	case conn := <-rc.connPool:
+		return conn, nil
+	default:
+	}
+
+	conn, err := rc.createConnection()
+	if err != nil {
+		<-rc.slots
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (rc *RedisClient) putConn(conn net.Conn) {
+	defer func() { <-rc.slots }()
+	rc.returnIdle(conn)
+}
+
+// discardConn drops a connection that saw a network error.
+func (rc *RedisClient) discardConn(conn net.Conn) {
+	conn.Close()
+	<-rc.slots
+}
+
+func (rc *RedisClient) returnIdle(conn net.Conn) {
+	if pc, ok := conn.(*pooledConn); ok && pc.gen != rc.generation.Load() {
+		conn.Close()
+		return
+	}
+
+	select {
+	case <-rc.done:
+		conn.Close()
+		return
+	default:
+	}
+
+	select {
+	case rc.connPool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (rc *RedisClient) drainIdle() {
+	for {
+		select {
+		case conn := <-rc.connPool:
+			conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+// fillIdle dials connections until MinIdle are idle.
+func (rc *RedisClient) fillIdle() error {
+	for len(rc.connPool) < rc.opts.MinIdle {
+		conn, err := rc.createConnection()
+		if err != nil {
+			return err
+		}
+		rc.returnIdle(conn)
+	}
+	return nil
+}
+
+func (rc *RedisClient) recordFailure(err error) {
+	if rc.breaker.failure() {
+		rc.logger.Warn("Redis circuit opened, cache calls are short-circuited", "error", err)
+	}
+}
+
+func (rc *RedisClient) recordSuccess() {
+	if rc.breaker.success() {
+		rc.logger.Info("Redis connection restored", "addr", rc.Addr())
+	}
+}
+
+// CircuitState reports the breaker state: "closed", "open" or "half-open".
+func (rc *RedisClient) CircuitState() string {
+	return rc.breaker.current().String()
+}
+
+// connectionManager health-checks idle connections, keeps MinIdle warm and
+// probes Redis while the circuit is open so the client recovers on its own.
+func (rc *RedisClient) connectionManager() {
+	for {
+		select {
+		case <-rc.done:
+			return
+		case <-time.After(rc.reconnTime):
+		}
+
+		if rc.breaker.current() != circuitClosed {
+			if !rc.breaker.allow() {
+				continue
+			}
+			conn, err := rc.createConnection()
+			if err != nil {
+				rc.recordFailure(err)
+				continue
+			}
+			rc.recordSuccess()
+			rc.returnIdle(conn)
+		}
+
+		// Only idle connections are checked, busy ones prove themselves
+		for i, n := 0, len(rc.connPool); i < n; i++ {
+			var conn net.Conn
+			select {
+			case conn = <-rc.connPool:
+			default:
+			}
+			if conn == nil {
+				break
+			}
+
+			if err := rc.checkConnection(conn); err != nil {
+				conn.Close()
+				continue
+			}
+			rc.returnIdle(conn)
+		}
+
+		if err := rc.fillIdle(); err != nil {
+			rc.recordFailure(err)
+		}
+	}
+}
+
+func (rc *RedisClient) checkConnection(conn net.Conn) error {
+	if conn == nil {
+		return fmt.Errorf("connection is nil")
+	}
+
+	conn.SetDeadline(time.Now().Add(500 * time.Millisecond))
+	defer conn.SetDeadline(time.Time{})
+
+	_, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	if err != nil {
+		return fmt.Errorf("ping failed: %v", err)
+	}
+
+	buf := make([]byte, 1024)
+	_, err = conn.Read(buf)
+	if err != nil || !strings.Contains(string(buf), "+PONG") {
+		return fmt.Errorf("invalid ping response: %v", err)
+	}
+
+	return nil
+}
@@ -3,163 +3,335 @@ package cache
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"marketflow/pkg/metrics"
+)
+
+var (
+	commandSeconds = metrics.NewHistogramVec("marketflow_redis_command_duration_seconds", "Redis command latency including the wait for a connection.", nil, "command")
+	commandErrors  = metrics.NewCounterVec("marketflow_redis_command_errors_total", "Redis commands that failed, including those rejected by the open circuit.", "command")
 )
 
+// DefaultPriceRetention is how long raw ticks are kept in the per-pair
+// sorted sets unless Options.PriceRetention says otherwise.
+const DefaultPriceRetention = 2 * time.Minute
+
+// Options holds the connection settings sent to Redis on every new connection.
+type Options struct {
+	Username      string
+	Password      string
+	DB            int
+	ClientName    string
+	TLS           bool
+	TLSCAFile     string
+	TLSServerName string
+	TLSSkipVerify bool
+
+	// When SentinelAddrs is set the master address is discovered from
+	// Sentinel and the pool follows +switch-master notifications.
+	SentinelAddrs    []string
+	SentinelPassword string
+	MasterName       string
+
+	// Pool tuning. Connections are dialed lazily up to the pool size passed to
+	// NewRedisClient; MinIdle are kept warm and at most MaxIdle are kept around.
+	MinIdle          int
+	MaxIdle          int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	PriceRetention time.Duration
+}
+
+// RedisError is an error reply sent by the server. The connection that
+// received it is still usable.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis error: " + string(e)
+}
+
 type RedisClient struct {
-	Addr       string
+	addr       string
+	addrMu     sync.RWMutex
+	generation atomic.Uint64
+	opts       Options
+	retention  atomic.Int64 // time.Duration; changes on config reload
+	tickSeq    atomic.Uint64
+	tlsConfig  *tls.Config
 	logger     *slog.Logger
 	mu         sync.Mutex
 	poolSize   int
-	connPool   chan net.Conn
+	connPool   chan net.Conn // idle connections
+	slots      chan struct{} // one token per checked-out connection
+	breaker    *circuitBreaker
 	done       chan struct{}
 	reconnTime time.Duration
 }
 
-func NewRedisClient(addr string, logger *slog.Logger, poolSize int) (*RedisClient, error) {
+func NewRedisClient(addr string, logger *slog.Logger, poolSize int, opts Options) (*RedisClient, error) {
+	if poolSize <= 0 {
+		poolSize = 50
+	}
+	if opts.MaxIdle <= 0 || opts.MaxIdle > poolSize {
+		opts.MaxIdle = min(poolSize, 10)
+	}
+	if opts.MinIdle < 0 || opts.MinIdle > opts.MaxIdle {
+		opts.MinIdle = opts.MaxIdle
+	}
+	if opts.BreakerThreshold <= 0 {
+		opts.BreakerThreshold = 5
+	}
+	if opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = 5 * time.Second
+	}
+	if opts.PriceRetention <= 0 {
+		opts.PriceRetention = DefaultPriceRetention
+	}
+
 	rc := &RedisClient{
-		Addr:       addr,
+		addr:       addr,
+		opts:       opts,
 		logger:     logger,
 		poolSize:   poolSize,
-		connPool:   make(chan net.Conn, poolSize),
+		connPool:   make(chan net.Conn, opts.MaxIdle),
+		slots:      make(chan struct{}, poolSize),
+		breaker:    newCircuitBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
 		done:       make(chan struct{}),
 		reconnTime: 1 * time.Second,
 	}
+	rc.retention.Store(int64(opts.PriceRetention))
 
-	// Initialize connection pool
-	for i := 0; i < poolSize; i++ {
-		conn, err := rc.createConnection()
+	if len(opts.SentinelAddrs) > 0 {
+		masterAddr, err := rc.resolveMaster()
 		if err != nil {
-			rc.Close() // Clean up any created connections
-			return nil, fmt.Errorf("failed to initialize connection pool: %v", err)
+			// The watcher keeps asking Sentinel and switches once it answers
+			logger.Warn("Sentinel unavailable at startup, using configured address", "addr", addr, "error", err)
+		} else {
+			rc.addr = masterAddr
+			addr = masterAddr
 		}
-		rc.connPool <- conn
+	}
+
+	if opts.TLS {
+		tlsConfig, err := buildTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		rc.tlsConfig = tlsConfig
+	}
+
+	// Warm up the pool; Redis being down is not fatal, the client
+	// keeps retrying in the background and short-circuits calls meanwhile.
+	if err := rc.fillIdle(); err != nil {
+		logger.Warn("Redis unavailable at startup, continuing without cache", "addr", addr, "error", err)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if err := rc.LoadScript(ctx, addTickScript); err != nil {
+			rc.logger.Warn("Failed to preload Lua script, will fall back to EVAL", "error", err)
+		}
+		cancel()
 	}
 
 	// Start background reconnection goroutine
 	go rc.connectionManager()
 
+	if len(opts.SentinelAddrs) > 0 {
+		go rc.watchSentinel()
+	}
+
 	return rc, nil
 }
 
+// buildTLSConfig leaves ServerName empty unless TLSServerName is set;
+// createConnection then verifies against the host it dials, which follows
+// Sentinel failovers.
+func buildTLSConfig(opts Options) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         opts.TLSServerName,
+		InsecureSkipVerify: opts.TLSSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if opts.TLSCAFile != "" {
+		pem, err := os.ReadFile(opts.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read redis CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
 func (rc *RedisClient) createConnection() (net.Conn, error) {
-	conn, err := net.DialTimeout("tcp", rc.Addr, 2*time.Second)
+	dialer := &net.Dialer{
+		Timeout:   2 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	// Tag the connection with the generation of the address it was dialed
+	// for, so one racing a failover is dropped instead of pooled
+	addr, gen := rc.target()
+	conn, err := dialer.Dial("tcp", addr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
 	}
 
 	if tcpConn, ok := conn.(*net.TCPConn); ok {
-		tcpConn.SetKeepAlive(true)
-		tcpConn.SetKeepAlivePeriod(30 * time.Second)
 		tcpConn.SetNoDelay(true)
 	}
 
-	// Verify connection
+	if rc.tlsConfig != nil {
+		tlsConfig := rc.tlsConfig
+		if tlsConfig.ServerName == "" {
+			host, _, _ := net.SplitHostPort(addr)
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName = host
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		tlsConn.SetDeadline(time.Now().Add(2 * time.Second))
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis TLS handshake failed: %v", err)
+		}
+		conn = tlsConn
+	}
+
 	conn.SetDeadline(time.Now().Add(2 * time.Second))
 	defer conn.SetDeadline(time.Time{})
 
-	_, err = conn.Write([]byte("*1\r\n$4\r\nPING\r\n"))
-	if err != nil {
+	if err := rc.handshake(conn); err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("redis ping failed: %v", err)
+		return nil, err
 	}
 
-	buf := make([]byte, 1024)
-	_, err = conn.Read(buf)
-	if err != nil || !strings.Contains(string(buf), "+PONG") {
-		conn.Close()
-		return nil, fmt.Errorf("redis ping response invalid: %v", err)
-	}
+	return &pooledConn{Conn: conn, gen: gen}, nil
+}
 
-	return conn, nil
+// pooledConn remembers which master generation it was dialed for so that
+// connections to a demoted master are dropped instead of returned to the pool.
+type pooledConn struct {
+	net.Conn
+	gen uint64
 }
 
-func (rc *RedisClient) connectionManager() {
-	for {
-		select {
-		case <-rc.done:
-			return
-		case <-time.After(rc.reconnTime):
-			rc.mu.Lock()
-			// Check all connections in pool
-			for i := 0; i < len(rc.connPool); i++ {
-				conn := <-rc.connPool
-				if err := rc.checkConnection(conn); err != nil {
-					conn.Close()
-					newConn, err := rc.createConnection()
-					if err != nil {
-						rc.logger.Error("Failed to recreate connection", "error", err)
-						continue
-					}
-					rc.connPool <- newConn
-				} else {
-					rc.connPool <- conn
-				}
-			}
-			rc.mu.Unlock()
+// Addr returns the address of the Redis server the pool currently talks to.
+func (rc *RedisClient) Addr() string {
+	rc.addrMu.RLock()
+	defer rc.addrMu.RUnlock()
+	return rc.addr
+}
+
+// target returns the current address with its master generation.
+func (rc *RedisClient) target() (string, uint64) {
+	rc.addrMu.RLock()
+	defer rc.addrMu.RUnlock()
+	return rc.addr, rc.generation.Load()
+}
+
+// handshake authenticates, selects the database and names the connection,
+// then verifies it with a PING.
+func (rc *RedisClient) handshake(conn net.Conn) error {
+	reader := bufio.NewReader(conn)
+
+	if rc.opts.Password != "" {
+		args := []string{rc.opts.Password}
+		if rc.opts.Username != "" {
+			args = []string{rc.opts.Username, rc.opts.Password}
+		}
+		if _, err := sendCommand(conn, reader, "AUTH", args...); err != nil {
+			return fmt.Errorf("redis AUTH failed: %v", err)
 		}
 	}
-}
 
-func (rc *RedisClient) checkConnection(conn net.Conn) error {
-	if conn == nil {
-		return fmt.Errorf("connection is nil")
+	if rc.opts.DB != 0 {
+		if _, err := sendCommand(conn, reader, "SELECT", strconv.Itoa(rc.opts.DB)); err != nil {
+			return fmt.Errorf("redis SELECT failed: %v", err)
+		}
 	}
 
-	conn.SetDeadline(time.Now().Add(500 * time.Millisecond))
-	defer conn.SetDeadline(time.Time{})
+	if rc.opts.ClientName != "" {
+		if _, err := sendCommand(conn, reader, "CLIENT", "SETNAME", rc.opts.ClientName); err != nil {
+			return fmt.Errorf("redis CLIENT SETNAME failed: %v", err)
+		}
+	}
 
-	_, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	resp, err := sendCommand(conn, reader, "PING")
 	if err != nil {
-		return fmt.Errorf("ping failed: %v", err)
+		return fmt.Errorf("redis ping failed: %v", err)
 	}
-
-	buf := make([]byte, 1024)
-	_, err = conn.Read(buf)
-	if err != nil || !strings.Contains(string(buf), "+PONG") {
-		return fmt.Errorf("invalid ping response: %v", err)
+	if len(resp) == 0 || resp[0] != "PONG" {
+		return fmt.Errorf("redis ping response invalid: %v", resp)
 	}
 
 	return nil
 }
 
-func (rc *RedisClient) getConn() (net.Conn, error) {
-	select {
-	case conn := <-rc.connPool:
-		return conn, nil
-	case <-time.After(500 * time.Millisecond):
-		return nil, fmt.Errorf("connection pool timeout")
-	}
-}
+func (rc *RedisClient) execCommand(ctx context.Context, cmd string, args ...string) (resp []string, err error) {
+	name := strings.ToUpper(cmd)
+	defer func(start time.Time) {
+		commandSeconds.With(name).ObserveSince(start)
+		if err != nil {
+			commandErrors.With(name).Inc()
+		}
+	}(time.Now())
 
-func (rc *RedisClient) putConn(conn net.Conn) {
-	select {
-	case rc.connPool <- conn:
-	default:
-		conn.Close()
+	if !rc.breaker.allow() {
+		return nil, ErrCircuitOpen
 	}
-}
 
-func (rc *RedisClient) execCommand(ctx context.Context, cmd string, args ...string) ([]string, error) {
 	conn, err := rc.getConn()
 	if err != nil {
+		if err != errPoolTimeout {
+			rc.recordFailure(err)
+		}
 		return nil, fmt.Errorf("failed to get connection: %v", err)
 	}
-	defer rc.putConn(conn)
 
 	deadline, ok := ctx.Deadline()
 	if ok {
 		conn.SetDeadline(deadline)
-		defer conn.SetDeadline(time.Time{})
 	}
 
+	reader := bufio.NewReader(conn)
+	resp, err = sendCommand(conn, reader, cmd, args...)
+
+	// Error replies leave the connection usable, anything else does not
+	var redisErr RedisError
+	if err != nil && !errors.As(err, &redisErr) {
+		rc.discardConn(conn)
+		rc.recordFailure(err)
+		return nil, err
+	}
+
+	if ok {
+		conn.SetDeadline(time.Time{})
+	}
+	rc.putConn(conn)
+	rc.recordSuccess()
+
+	return resp, err
+}
+
+func encodeCommand(cmd string, args ...string) []byte {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("*%d\r\n", len(args)+1))
 	sb.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(cmd), cmd))
@@ -168,75 +340,115 @@ func (rc *RedisClient) execCommand(ctx context.Context, cmd string, args ...stri
 		sb.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg))
 	}
 
-	cmdStr := sb.String()
+	return []byte(sb.String())
+}
 
-	_, err = conn.Write([]byte(cmdStr))
-	if err != nil {
+func sendCommand(conn net.Conn, reader *bufio.Reader, cmd string, args ...string) ([]string, error) {
+	if _, err := conn.Write(encodeCommand(cmd, args...)); err != nil {
 		return nil, fmt.Errorf("failed to write command: %v", err)
 	}
-
-	reader := bufio.NewReader(conn)
 	return readRESP(reader)
 }
 
 func (rc *RedisClient) Close() {
-	close(rc.done)
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
-	close(rc.connPool)
-	for conn := range rc.connPool {
-		conn.Close()
+	select {
+	case <-rc.done:
+		return
+	default:
+	}
+	close(rc.done)
+	rc.drainIdle()
+}
+
+// LatestPrice is the most recent tick of a symbol on one exchange.
+type LatestPrice struct {
+	Exchange  string
+	Symbol    string
+	Price     float64
+	Timestamp time.Time
+}
+
+// GetLatestPrice returns the latest price for exchange/symbol, or the newest
+// across all exchanges when exchange is empty.
+func (rc *RedisClient) GetLatestPrice(ctx context.Context, exchange, symbol string) (*LatestPrice, error) {
+	var exchanges []string
+	if exchange != "" {
+		exchanges = []string{exchange}
 	}
+
+	prices, err := rc.GetLatestPrices(ctx, symbol, exchanges...)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := prices[0]
+	for _, p := range prices[1:] {
+		if p.Timestamp.After(latest.Timestamp) {
+			latest = p
+		}
+	}
+	return &latest, nil
 }
 
-func (rc *RedisClient) GetLatestPrice(ctx context.Context, exchange, symbol string) (float64, error) {
+// GetLatestPrices returns the latest price of symbol on each of the given
+// exchanges, or on every exchange that has one when none are given.
+func (rc *RedisClient) GetLatestPrices(ctx context.Context, symbol string, exchanges ...string) ([]LatestPrice, error) {
 	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
 	defer cancel()
 
 	var keys []string
-	if exchange == "" {
-		resp, err := rc.execCommand(ctx, "KEYS", fmt.Sprintf("price:*:%s", symbol))
+	if len(exchanges) == 0 {
+		resp, err := rc.execCommand(ctx, "KEYS", fmt.Sprintf("latest:*:%s", symbol))
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
 		keys = resp
 	} else {
-		keys = []string{fmt.Sprintf("price:%s:%s", exchange, symbol)}
+		for _, exchange := range exchanges {
+			keys = append(keys, fmt.Sprintf("latest:%s:%s", exchange, symbol))
+		}
 	}
 
-	var maxPrice float64
-	var maxTime int64
-	var found bool
-
+	var prices []LatestPrice
 	for _, key := range keys {
-		resp, err := rc.execCommand(ctx, "ZREVRANGE", key, "0", "0", "WITHSCORES")
-		if err != nil || len(resp) < 2 {
-			continue
+		resp, err := rc.execCommand(ctx, "HGETALL", key)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make(map[string]string, len(resp)/2)
+		for i := 0; i+1 < len(resp); i += 2 {
+			fields[resp[i]] = resp[i+1]
 		}
 
-		price, err := strconv.ParseFloat(resp[0], 64)
+		price, err := strconv.ParseFloat(fields["price"], 64)
 		if err != nil {
 			continue
 		}
-
-		timestamp, err := strconv.ParseInt(resp[1], 10, 64)
+		millis, err := strconv.ParseInt(fields["timestamp"], 10, 64)
 		if err != nil {
 			continue
 		}
 
-		if !found || timestamp > maxTime {
-			maxPrice = price
-			maxTime = timestamp
-			found = true
+		lp := LatestPrice{
+			Symbol:    symbol,
+			Price:     price,
+			Timestamp: time.UnixMilli(millis).UTC(),
+		}
+		if parts := strings.SplitN(key, ":", 3); len(parts) == 3 {
+			lp.Exchange = parts[1]
 		}
+		prices = append(prices, lp)
 	}
 
-	if !found {
-		return 0, fmt.Errorf("no prices found")
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("no prices found")
 	}
 
-	return maxPrice, nil
+	return prices, nil
 }
 
 func readRESP(reader *bufio.Reader) ([]string, error) {
@@ -277,6 +489,12 @@ func readRESP(reader *bufio.Reader) ([]string, error) {
 				return nil, fmt.Errorf("empty element type")
 			}
 
+			// Integers and simple strings show up in pub/sub replies
+			if typeLine[0] == ':' || typeLine[0] == '+' {
+				elements = append(elements, typeLine[1:])
+				continue
+			}
+
 			if typeLine[0] != '$' {
 				return nil, fmt.Errorf("expected bulk string type ($), got %q", typeLine)
 			}
@@ -310,7 +528,7 @@ func readRESP(reader *bufio.Reader) ([]string, error) {
 	case '+':
 		return []string{line[1:]}, nil
 	case '-':
-		return nil, fmt.Errorf("redis error: %s", line[1:])
+		return nil, RedisError(line[1:])
 	case ':':
 		return []string{line[1:]}, nil
 	case '$':
@@ -341,28 +559,27 @@ func readRESP(reader *bufio.Reader) ([]string, error) {
 }
 
 func (rc *RedisClient) AddPrice(ctx context.Context, exchange, symbol string, price float64) error {
-	key := "price:" + exchange + ":" + symbol
-	now := time.Now().Unix()
-
-	_, err := rc.execCommand(ctx, "ZADD", key, fmt.Sprintf("%d", now), fmt.Sprintf("%f", price))
-	if err != nil {
-		return fmt.Errorf("failed to add price: %v", err)
+	now := time.Now()
+	retention := rc.PriceRetention()
+	keys := []string{
+		"price:" + exchange + ":" + symbol,
+		"latest:" + exchange + ":" + symbol,
 	}
 
-	_, err = rc.execCommand(ctx, "EXPIRE", key, "120")
+	// Members must be unique, or equal prices within the window collapse into one
+	member := fmt.Sprintf("%d:%d:%f", now.UnixNano(), rc.tickSeq.Add(1), price)
+
+	_, err := rc.RunScript(ctx, addTickScript, keys,
+		strconv.FormatInt(now.UnixMilli(), 10),
+		member,
+		strconv.FormatInt(now.Add(-retention).UnixMilli(), 10),
+		strconv.Itoa(int(retention.Seconds())),
+		fmt.Sprintf("%f", price),
+	)
 	if err != nil {
-		rc.logger.Warn("Failed to set TTL for key", "key", key, "error", err)
+		return fmt.Errorf("failed to add price: %w", err)
 	}
 
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-		defer cancel()
-
-		if err := rc.CleanOldPrices(ctx); err != nil {
-			rc.logger.Warn("Background cleanup failed", "error", err)
-		}
-	}()
-
 	return nil
 }
 
@@ -372,7 +589,7 @@ func (rc *RedisClient) CleanOldPrices(ctx context.Context) error {
 		return fmt.Errorf("failed to fetch keys for cleanup: %v", err)
 	}
 
-	expireBefore := time.Now().Add(-2 * time.Minute).Unix()
+	expireBefore := time.Now().Add(-rc.PriceRetention()).UnixMilli()
 
 	for _, key := range resp {
 		_, err := rc.execCommand(ctx, "ZREMRANGEBYSCORE", key, "-inf", fmt.Sprintf("%d", expireBefore))
@@ -384,6 +601,27 @@ func (rc *RedisClient) CleanOldPrices(ctx context.Context) error {
 	return nil
 }
 
+// PriceRetention is how far back the raw tick sets reach.
+func (rc *RedisClient) PriceRetention() time.Duration {
+	return time.Duration(rc.retention.Load())
+}
+
+// tickPrice extracts the price from a price sorted-set member written by
+// AddPrice ("<unixnano>:<seq>:<price>").
+func tickPrice(member string) (float64, error) {
+	i := strings.LastIndexByte(member, ':')
+	return strconv.ParseFloat(member[i+1:], 64)
+}
+
+// SetPriceRetention changes the retention for ticks written from now on;
+// sorted sets shrink to it on their next write or cleanup pass.
+func (rc *RedisClient) SetPriceRetention(d time.Duration) {
+	if d <= 0 {
+		d = DefaultPriceRetention
+	}
+	rc.retention.Store(int64(d))
+}
+
 func (rc *RedisClient) Ping() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Script is a Lua script addressed by its SHA1 so it can be run with EVALSHA.
+type Script struct {
+	src string
+	sha string
+}
+
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{
+		src: src,
+		sha: hex.EncodeToString(sum[:]),
+	}
+}
+
+func (s *Script) Hash() string {
+	return s.sha
+}
+
+// LoadScript uploads the script with SCRIPT LOAD so later EVALSHA calls hit the cache.
+func (rc *RedisClient) LoadScript(ctx context.Context, s *Script) error {
+	resp, err := rc.execCommand(ctx, "SCRIPT", "LOAD", s.src)
+	if err != nil {
+		return fmt.Errorf("failed to load script: %v", err)
+	}
+	if len(resp) == 0 || resp[0] != s.sha {
+		return fmt.Errorf("unexpected script hash: %v", resp)
+	}
+	return nil
+}
+
+// RunScript executes the script with EVALSHA and falls back to EVAL when the
+// server does not know the script yet (e.g. after a restart or SCRIPT FLUSH).
+func (rc *RedisClient) RunScript(ctx context.Context, s *Script, keys []string, args ...string) ([]string, error) {
+	cmdArgs := make([]string, 0, len(keys)+len(args)+2)
+	cmdArgs = append(cmdArgs, s.sha, strconv.Itoa(len(keys)))
+	cmdArgs = append(cmdArgs, keys...)
+	cmdArgs = append(cmdArgs, args...)
+
+	resp, err := rc.execCommand(ctx, "EVALSHA", cmdArgs...)
+	if err == nil || !strings.Contains(err.Error(), "NOSCRIPT") {
+		return resp, err
+	}
+
+	cmdArgs[0] = s.src
+	return rc.execCommand(ctx, "EVAL", cmdArgs...)
+}
+
+// addTickScript stores a tick, trims everything older than the retention
+// window, refreshes the TTL and updates the latest price hash in one step.
+//
+// KEYS[1] - price sorted set, KEYS[2] - latest hash
+// ARGV[1] - tick timestamp in milliseconds (the score), ARGV[2] - unique member
+// "<unixnano>:<seq>:<price>", ARGV[3] - trim cutoff in milliseconds,
+// ARGV[4] - TTL in seconds, ARGV[5] - price for the latest hash
+var addTickScript = NewScript(`
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[3])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+redis.call('HSET', KEYS[2], 'price', ARGV[5], 'timestamp', ARGV[1])
+redis.call('EXPIRE', KEYS[2], ARGV[4])
+return redis.call('ZCARD', KEYS[1])
+`)
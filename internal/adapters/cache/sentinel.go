@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// resolveMaster asks each configured Sentinel in turn for the current master.
+func (rc *RedisClient) resolveMaster() (string, error) {
+	var lastErr error
+	for _, sentinel := range rc.opts.SentinelAddrs {
+		addr, err := rc.queryMaster(sentinel)
+		if err != nil {
+			rc.logger.Warn("Sentinel query failed", "sentinel", sentinel, "error", err)
+			lastErr = err
+			continue
+		}
+		return addr, nil
+	}
+	return "", fmt.Errorf("no sentinel could resolve master %q: %v", rc.opts.MasterName, lastErr)
+}
+
+func (rc *RedisClient) queryMaster(sentinel string) (string, error) {
+	conn, reader, err := rc.dialSentinel(sentinel)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	resp, err := sendCommand(conn, reader, "SENTINEL", "get-master-addr-by-name", rc.opts.MasterName)
+	if err != nil {
+		return "", err
+	}
+	if len(resp) != 2 {
+		return "", fmt.Errorf("unknown master %q", rc.opts.MasterName)
+	}
+
+	return net.JoinHostPort(resp[0], resp[1]), nil
+}
+
+func (rc *RedisClient) dialSentinel(sentinel string) (net.Conn, *bufio.Reader, error) {
+	conn, err := net.DialTimeout("tcp", sentinel, 2*time.Second)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to sentinel: %v", err)
+	}
+	reader := bufio.NewReader(conn)
+
+	if rc.opts.SentinelPassword != "" {
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		if _, err := sendCommand(conn, reader, "AUTH", rc.opts.SentinelPassword); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("sentinel AUTH failed: %v", err)
+		}
+		conn.SetDeadline(time.Time{})
+	}
+
+	return conn, reader, nil
+}
+
+// watchSentinel subscribes to +switch-master on the first reachable Sentinel
+// and moves the pool to the new master whenever a failover is announced.
+func (rc *RedisClient) watchSentinel() {
+	for i := 0; ; i++ {
+		select {
+		case <-rc.done:
+			return
+		default:
+		}
+
+		sentinel := rc.opts.SentinelAddrs[i%len(rc.opts.SentinelAddrs)]
+		if err := rc.followSentinel(sentinel); err != nil {
+			rc.logger.Warn("Lost sentinel subscription", "sentinel", sentinel, "error", err)
+		}
+
+		select {
+		case <-rc.done:
+			return
+		case <-time.After(rc.reconnTime):
+		}
+	}
+}
+
+func (rc *RedisClient) followSentinel(sentinel string) error {
+	conn, reader, err := rc.dialSentinel(sentinel)
+	if err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-rc.done:
+		case <-stop:
+		}
+		conn.Close()
+	}()
+
+	if _, err := sendCommand(conn, reader, "SUBSCRIBE", "+switch-master"); err != nil {
+		return err
+	}
+
+	// A failover may have happened while we were not subscribed
+	if addr, err := rc.queryMaster(sentinel); err == nil && addr != rc.Addr() {
+		rc.switchMaster(addr)
+	}
+
+	for {
+		msg, err := readRESP(reader)
+		if err != nil {
+			return err
+		}
+		if len(msg) != 3 || msg[0] != "message" {
+			continue
+		}
+
+		// <master name> <old ip> <old port> <new ip> <new port>
+		fields := strings.Fields(msg[2])
+		if len(fields) != 5 || fields[0] != rc.opts.MasterName {
+			continue
+		}
+		rc.switchMaster(net.JoinHostPort(fields[3], fields[4]))
+	}
+}
+
+// switchMaster points the client at a new master and rebuilds the idle pool.
+func (rc *RedisClient) switchMaster(addr string) {
+	// Address and generation change together; see target
+	rc.addrMu.Lock()
+	old := rc.addr
+	rc.addr = addr
+	rc.generation.Add(1)
+	rc.addrMu.Unlock()
+
+	rc.logger.Warn("Redis master switched", "from", old, "to", addr)
+
+	// Connections to the old master are closed as they come back to the pool
+	rc.drainIdle()
+
+	if err := rc.fillIdle(); err != nil {
+		rc.logger.Error("Failed to connect to new master", "addr", addr, "error", err)
+	}
+}
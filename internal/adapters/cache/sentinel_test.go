@@ -0,0 +1,339 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// fakeRedis speaks enough RESP for the client's handshake and answers GET
+// with its own name, so tests can tell which server a command reached.
+type fakeRedis struct {
+	name string
+	ln   net.Listener
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func startFakeRedis(t *testing.T, name string, tlsConfig *tls.Config) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+	f := &fakeRedis{name: name, ln: ln}
+	t.Cleanup(f.close)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns = append(f.conns, conn)
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) addr() string { return f.ln.Addr().String() }
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		cmd, err := readRESP(reader)
+		if err != nil || len(cmd) == 0 {
+			return
+		}
+		var reply string
+		switch strings.ToUpper(cmd[0]) {
+		case "PING":
+			reply = "+PONG\r\n"
+		case "AUTH", "SELECT", "CLIENT":
+			reply = "+OK\r\n"
+		case "GET":
+			reply = fmt.Sprintf("$%d\r\n%s\r\n", len(f.name), f.name)
+		default:
+			reply = "-ERR unknown command '" + cmd[0] + "'\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) close() {
+	f.ln.Close()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.conns {
+		c.Close()
+	}
+}
+
+// fakeSentinel answers get-master-addr-by-name and pushes +switch-master
+// to its subscribers on failover.
+type fakeSentinel struct {
+	ln net.Listener
+
+	mu          sync.Mutex
+	master      string
+	subscribers []net.Conn
+}
+
+func startFakeSentinel(t *testing.T, master string) *fakeSentinel {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSentinel{ln: ln, master: master}
+	t.Cleanup(func() {
+		ln.Close()
+		s.dropSubscribers()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSentinel) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeSentinel) serve(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		cmd, err := readRESP(reader)
+		if err != nil || len(cmd) == 0 {
+			conn.Close()
+			return
+		}
+		switch {
+		case len(cmd) == 3 && strings.EqualFold(cmd[0], "SENTINEL") && cmd[2] == "mymaster":
+			s.mu.Lock()
+			host, port, _ := net.SplitHostPort(s.master)
+			s.mu.Unlock()
+			fmt.Fprintf(conn, "*2\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(host), host, len(port), port)
+		case len(cmd) == 3 && strings.EqualFold(cmd[0], "SENTINEL"):
+			conn.Write([]byte("*-1\r\n"))
+		case strings.EqualFold(cmd[0], "SUBSCRIBE"):
+			s.mu.Lock()
+			fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(cmd[1]), cmd[1])
+			s.subscribers = append(s.subscribers, conn)
+			s.mu.Unlock()
+		default:
+			conn.Write([]byte("-ERR unknown command\r\n"))
+		}
+	}
+}
+
+// failover moves the master and, when announce is set, tells subscribers.
+func (s *fakeSentinel) failover(to string, announce bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oldHost, oldPort, _ := net.SplitHostPort(s.master)
+	newHost, newPort, _ := net.SplitHostPort(to)
+	s.master = to
+	if !announce {
+		return
+	}
+
+	msg := strings.Join([]string{"mymaster", oldHost, oldPort, newHost, newPort}, " ")
+	for _, c := range s.subscribers {
+		fmt.Fprintf(c, "*3\r\n$7\r\nmessage\r\n$14\r\n+switch-master\r\n$%d\r\n%s\r\n", len(msg), msg)
+	}
+}
+
+func (s *fakeSentinel) dropSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.subscribers {
+		c.Close()
+	}
+	s.subscribers = nil
+}
+
+func (s *fakeSentinel) subscribed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers) > 0
+}
+
+func newSentinelClient(t *testing.T, s *fakeSentinel, opts Options) *RedisClient {
+	t.Helper()
+	opts.SentinelAddrs = []string{s.addr()}
+	opts.MasterName = "mymaster"
+	opts.MinIdle, opts.MaxIdle = 2, 4
+
+	// The configured address is only a fallback; Sentinel must win
+	rc, err := NewRedisClient("redis.invalid:6379", discard, 4, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func get(t *testing.T, rc *RedisClient) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := rc.execCommand(ctx, "GET", "whoami")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	return resp[0]
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSentinelFailover(t *testing.T) {
+	a := startFakeRedis(t, "a", nil)
+	b := startFakeRedis(t, "b", nil)
+	s := startFakeSentinel(t, a.addr())
+
+	rc := newSentinelClient(t, s, Options{})
+	if rc.Addr() != a.addr() {
+		t.Fatalf("Addr = %s, want the master Sentinel reported (%s)", rc.Addr(), a.addr())
+	}
+	if got := get(t, rc); got != "a" {
+		t.Fatalf("GET reached %q before failover", got)
+	}
+	eventually(t, "the Sentinel subscription", s.subscribed)
+
+	// A command in flight across the failover still holds a connection to a
+	held, err := rc.getConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.failover(b.addr(), true)
+	eventually(t, "the switch to b", func() bool { return rc.Addr() == b.addr() })
+
+	// Returning it must close it rather than pool a connection to the old master
+	rc.putConn(held)
+	for i := 0; i < 2*cap(rc.connPool); i++ {
+		if got := get(t, rc); got != "b" {
+			t.Fatalf("GET %d after failover reached %q", i, got)
+		}
+	}
+}
+
+func TestSentinelFailoverMissedWhileUnsubscribed(t *testing.T) {
+	a := startFakeRedis(t, "a", nil)
+	b := startFakeRedis(t, "b", nil)
+	s := startFakeSentinel(t, a.addr())
+
+	rc := newSentinelClient(t, s, Options{})
+	eventually(t, "the Sentinel subscription", s.subscribed)
+
+	// The failover happens while the client is between subscriptions; it
+	// must ask for the master again when it resubscribes
+	s.dropSubscribers()
+	s.failover(b.addr(), false)
+
+	eventually(t, "the switch to b", func() bool { return rc.Addr() == b.addr() })
+	if got := get(t, rc); got != "b" {
+		t.Fatalf("GET after resubscribing reached %q", got)
+	}
+}
+
+func TestSentinelIgnoresOtherMasters(t *testing.T) {
+	a := startFakeRedis(t, "a", nil)
+	s := startFakeSentinel(t, a.addr())
+
+	rc := newSentinelClient(t, s, Options{})
+	eventually(t, "the Sentinel subscription", s.subscribed)
+	gen := rc.generation.Load()
+
+	s.mu.Lock()
+	msg := "othermaster 127.0.0.1 1 127.0.0.1 2"
+	for _, c := range s.subscribers {
+		fmt.Fprintf(c, "*3\r\n$7\r\nmessage\r\n$14\r\n+switch-master\r\n$%d\r\n%s\r\n", len(msg), msg)
+	}
+	s.mu.Unlock()
+
+	time.Sleep(100 * time.Millisecond)
+	if rc.Addr() != a.addr() || rc.generation.Load() != gen {
+		t.Errorf("switched to %s for another master's failover", rc.Addr())
+	}
+}
+
+// With TLS and no TLSServerName the certificate is checked against the
+// master Sentinel reported, not the configured fallback address.
+func TestSentinelTLSVerifiesDialedMaster(t *testing.T) {
+	// httptest's certificate is valid for 127.0.0.1
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	certSrv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certSrv.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	serverTLS := &tls.Config{Certificates: certSrv.TLS.Certificates}
+
+	a := startFakeRedis(t, "a", serverTLS)
+	b := startFakeRedis(t, "b", serverTLS)
+	s := startFakeSentinel(t, a.addr())
+
+	rc := newSentinelClient(t, s, Options{TLS: true, TLSCAFile: caFile})
+	if got := get(t, rc); got != "a" {
+		t.Fatalf("GET reached %q", got)
+	}
+
+	eventually(t, "the Sentinel subscription", s.subscribed)
+	s.failover(b.addr(), true)
+	eventually(t, "the switch to b", func() bool { return rc.Addr() == b.addr() })
+	if got := get(t, rc); got != "b" {
+		t.Fatalf("GET after failover reached %q", got)
+	}
+
+	// A pinned name that the certificate does not cover must fail
+	pinned, err := NewRedisClient(a.addr(), discard, 1, Options{TLS: true, TLSCAFile: caFile, TLSServerName: "redis.invalid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pinned.Close()
+	if _, err := pinned.createConnection(); err == nil || !strings.Contains(err.Error(), "TLS handshake") {
+		t.Errorf("createConnection with a wrong TLSServerName: %v", err)
+	}
+}
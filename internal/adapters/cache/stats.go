@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrWindowTooLong is returned when the requested window is older than
+// what Redis still keeps (see Options.PriceRetention).
+var ErrWindowTooLong = errors.New("window exceeds redis retention")
+
+// WindowStats summarises the ticks of one exchange/symbol within a window.
+// MinAt/MaxAt are when the extremes were seen, LastAt the newest tick.
+type WindowStats struct {
+	Exchange string
+	Symbol   string
+	Min      float64
+	Max      float64
+	Avg      float64
+	Count    int
+	MinAt    time.Time
+	MaxAt    time.Time
+	LastAt   time.Time
+}
+
+// GetWindowStats computes min/max/avg per exchange over the ticks received
+// during the last window. An empty exchange returns every exchange holding
+// the symbol.
+func (rc *RedisClient) GetWindowStats(ctx context.Context, exchange, symbol string, window time.Duration) ([]WindowStats, error) {
+	if window <= 0 || window > rc.PriceRetention() {
+		return nil, ErrWindowTooLong
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	var keys []string
+	if exchange == "" {
+		resp, err := rc.execCommand(ctx, "KEYS", fmt.Sprintf("price:*:%s", symbol))
+		if err != nil {
+			return nil, err
+		}
+		keys = resp
+	} else {
+		keys = []string{fmt.Sprintf("price:%s:%s", exchange, symbol)}
+	}
+
+	from := strconv.FormatInt(time.Now().Add(-window).UnixMilli(), 10)
+
+	var result []WindowStats
+	for _, key := range keys {
+		resp, err := rc.execCommand(ctx, "ZRANGEBYSCORE", key, from, "+inf", "WITHSCORES")
+		if err != nil {
+			return nil, err
+		}
+
+		stats := WindowStats{Symbol: symbol}
+		if parts := strings.SplitN(key, ":", 3); len(parts) == 3 {
+			stats.Exchange = parts[1]
+		}
+
+		var sum float64
+		for i := 0; i+1 < len(resp); i += 2 {
+			price, err := tickPrice(resp[i])
+			if err != nil {
+				continue
+			}
+			score, err := strconv.ParseFloat(resp[i+1], 64)
+			if err != nil {
+				continue
+			}
+			at := time.UnixMilli(int64(score)).UTC()
+
+			if stats.Count == 0 || price < stats.Min {
+				stats.Min, stats.MinAt = price, at
+			}
+			if stats.Count == 0 || price > stats.Max {
+				stats.Max, stats.MaxAt = price, at
+			}
+			if at.After(stats.LastAt) {
+				stats.LastAt = at
+			}
+			sum += price
+			stats.Count++
+		}
+
+		if stats.Count > 0 {
+			stats.Avg = sum / float64(stats.Count)
+			result = append(result, stats)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no prices found")
+	}
+
+	return result, nil
+}
@@ -10,26 +10,58 @@ import (
 	"time"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/feeds"
+	"marketflow/pkg/metrics"
+)
+
+var (
+	ticksTotal        = metrics.NewCounterVec("marketflow_exchange_ticks_total", "Price messages received from an exchange.", "exchange")
+	reconnectsTotal   = metrics.NewCounterVec("marketflow_exchange_reconnects_total", "Connection attempts after the first one.", "exchange")
+	decodeErrorsTotal = metrics.NewCounterVec("marketflow_exchange_decode_errors_total", "Messages that could not be decoded.", "exchange")
+	droppedTotal      = metrics.NewCounterVec("marketflow_exchange_dropped_total", "Generated test ticks dropped because fan-in was full.", "exchange")
 )
 
 type PriceMessage struct {
 	Symbol    string  `json:"symbol"`
 	Price     float64 `json:"price"`
+	Quantity  float64 `json:"quantity,omitempty"`
 	Timestamp int64   `json:"timestamp"`
 }
 
-func ListenToExchange(address, exchangeName string, out chan<- domain.PriceUpdate, logger *slog.Logger) {
+// Source is an exchange simulator to connect to in live mode.
+type Source struct {
+	Name string
+	Addr string
+}
+
+// ListenToExchange reads ticks from address until ctx is cancelled,
+// reconnecting after reconnectDelay whenever the connection fails.
+func ListenToExchange(ctx context.Context, address, exchangeName string, reconnectDelay time.Duration, out chan<- domain.PriceUpdate, monitor *feeds.Monitor, logger *slog.Logger) {
 	logger = logger.With("exchange", exchangeName)
+	var dialer net.Dialer
 
-	for {
-		conn, err := net.Dial("tcp", address)
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		if attempt > 0 {
+			reconnectsTotal.With(exchangeName).Inc()
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
 			logger.Error("Failed to connect", "error", err)
-			time.Sleep(5 * time.Second)
+			monitor.ConnectFailed(exchangeName, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(reconnectDelay):
+			}
 			continue
 		}
 
 		logger.Info("Connected to exchange", "address", address)
+		monitor.Connected(exchangeName)
+		// Closing the connection unblocks the scanner; lines already read are still delivered
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
 
 		scanner := bufio.NewScanner(conn)
 		for scanner.Scan() {
@@ -37,6 +69,19 @@ func ListenToExchange(address, exchangeName string, out chan<- domain.PriceUpdat
 			var msg PriceMessage
 			if err := json.Unmarshal([]byte(line), &msg); err != nil {
 				logger.Warn("Failed to parse JSON message", "message", line, "error", err)
+				// A bad price field still leaves the symbol readable
+				var partial struct {
+					Symbol string `json:"symbol"`
+				}
+				json.Unmarshal([]byte(line), &partial)
+				monitor.DecodeError(exchangeName, partial.Symbol)
+				decodeErrorsTotal.With(exchangeName).Inc()
+				continue
+			}
+			if msg.Symbol == "" {
+				logger.Warn("Message without symbol", "message", line)
+				monitor.DecodeError(exchangeName, "")
+				decodeErrorsTotal.With(exchangeName).Inc()
 				continue
 			}
 
@@ -44,25 +89,37 @@ func ListenToExchange(address, exchangeName string, out chan<- domain.PriceUpdat
 				Exchange:   exchangeName,
 				Symbol:     msg.Symbol,
 				Price:      msg.Price,
+				Quantity:   msg.Quantity,
 				ReceivedAt: time.Now(),
 				Type:       "raw",
 			}
+			ticksTotal.With(exchangeName).Inc()
 			out <- update
 		}
 
-		if err := scanner.Err(); err != nil {
+		stop()
+		conn.Close()
+		if ctx.Err() != nil {
+			break
+		}
+		err = scanner.Err()
+		if err != nil {
 			logger.Error("Connection error", "error", err)
 		}
-		conn.Close()
+		monitor.Disconnected(exchangeName, err)
 		logger.Info("Connection closed, reconnecting...")
 	}
+
+	monitor.Disconnected(exchangeName, nil)
+	logger.Info("Listener stopped")
 }
 
 var testPairs = []string{"BTCUSDT", "ETHUSDT", "DOGEUSDT", "TONUSDT", "SOLUSDT"}
 
-func StartTestGenerators(ctx context.Context, out chan<- domain.PriceUpdate) {
-	exchanges := []string{"binance", "coinbase", "kucoin"}
-	for _, exchange := range exchanges {
+// StartTestGenerators emits synthetic ticks for the named exchanges until
+// ctx is cancelled, using the live sources' names so both modes match.
+func StartTestGenerators(ctx context.Context, names []string, out chan<- domain.PriceUpdate) {
+	for _, exchange := range names {
 		go generateTestData(ctx, exchange, out)
 	}
 }
@@ -83,6 +140,7 @@ func generateTestData(ctx context.Context, exchange string, out chan<- domain.Pr
 					Exchange:   exchange,
 					Symbol:     pair,
 					Price:      price,
+					Quantity:   rand.Float64() * 10,
 					ReceivedAt: time.Now(),
 					Type:       "raw",
 				}
@@ -90,6 +148,7 @@ func generateTestData(ctx context.Context, exchange string, out chan<- domain.Pr
 				select {
 				case out <- update:
 				default:
+					droppedTotal.With(exchange).Inc()
 					slog.Warn("Channel full, dropping test data")
 				}
 			}
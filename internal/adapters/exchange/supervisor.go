@@ -0,0 +1,67 @@
+package exchange
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/feeds"
+)
+
+type listener struct {
+	addr   string
+	cancel context.CancelFunc
+}
+
+// Supervisor owns the live-mode listeners. Apply is idempotent, so it can be
+// called on every mode check and on config reloads alike.
+type Supervisor struct {
+	out     chan<- domain.PriceUpdate
+	monitor *feeds.Monitor
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	running map[string]listener
+}
+
+func NewSupervisor(out chan<- domain.PriceUpdate, monitor *feeds.Monitor, logger *slog.Logger) *Supervisor {
+	return &Supervisor{
+		out:     out,
+		monitor: monitor,
+		logger:  logger,
+		running: make(map[string]listener),
+	}
+}
+
+// Apply makes the running listeners match sources: listeners whose address
+// changed or that are no longer listed are stopped, missing ones started and
+// the rest left connected. Apply(nil, 0) stops everything.
+func (s *Supervisor) Apply(sources []Source, reconnectDelay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]string, len(sources))
+	for _, src := range sources {
+		wanted[src.Name] = src.Addr
+	}
+
+	for name, l := range s.running {
+		if addr, ok := wanted[name]; !ok || addr != l.addr {
+			s.logger.Info("Stopping exchange listener", "exchange", name, "address", l.addr)
+			l.cancel()
+			delete(s.running, name)
+		}
+	}
+
+	for _, src := range sources {
+		if _, ok := s.running[src.Name]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s.running[src.Name] = listener{addr: src.Addr, cancel: cancel}
+		s.logger.Info("Starting exchange listener", "exchange", src.Name, "address", src.Addr)
+		go ListenToExchange(ctx, src.Addr, src.Name, reconnectDelay, s.out, s.monitor, s.logger)
+	}
+}
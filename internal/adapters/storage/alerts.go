@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+func InsertAlertRule(ctx context.Context, db *sql.DB, r *domain.AlertRule) error {
+	return db.QueryRowContext(ctx, `
+		INSERT INTO alert_rules
+			(type, symbol, exchange, level, direction, percent, window_seconds, spread_bps,
+			 stale_seconds, webhook_url, secret, cooldown_seconds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at`,
+		r.Type, r.Symbol, r.Exchange, r.Level, r.Direction, r.Percent, int(r.Window.Seconds()), r.SpreadBps,
+		int(r.StaleAfter.Seconds()), r.WebhookURL, r.Secret, int(r.Cooldown.Seconds()),
+	).Scan(&r.ID, &r.CreatedAt)
+}
+
+func ListAlertRules(ctx context.Context, db *sql.DB) ([]domain.AlertRule, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, type, symbol, exchange, level, direction, percent, window_seconds, spread_bps,
+			stale_seconds, webhook_url, secret, cooldown_seconds, created_at
+		FROM alert_rules
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.AlertRule
+	for rows.Next() {
+		var r domain.AlertRule
+		var window, stale, cooldown int
+		if err := rows.Scan(&r.ID, &r.Type, &r.Symbol, &r.Exchange, &r.Level, &r.Direction, &r.Percent, &window,
+			&r.SpreadBps, &stale, &r.WebhookURL, &r.Secret, &cooldown, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.Window = time.Duration(window) * time.Second
+		r.StaleAfter = time.Duration(stale) * time.Second
+		r.Cooldown = time.Duration(cooldown) * time.Second
+		rules = append(rules, r)
+	}
+
+	return rules, rows.Err()
+}
+
+// DeleteAlertRule reports whether a rule with that id existed.
+func DeleteAlertRule(ctx context.Context, db *sql.DB, id int64) (bool, error) {
+	res, err := db.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
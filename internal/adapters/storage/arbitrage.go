@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+func InsertOpportunity(ctx context.Context, db *sql.DB, o *domain.ArbitrageOpportunity) error {
+	return db.QueryRowContext(ctx, `
+		INSERT INTO arbitrage_opportunities
+			(symbol, buy_exchange, sell_exchange, buy_price, sell_price, spread_bps, net_spread_bps, max_net_spread_bps, started_at, detected_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		o.Symbol, o.BuyExchange, o.SellExchange, o.BuyPrice, o.SellPrice,
+		o.SpreadBps, o.NetSpreadBps, o.MaxNetSpreadBps, o.StartedAt, o.DetectedAt,
+	).Scan(&o.ID)
+}
+
+func CloseOpportunity(ctx context.Context, db *sql.DB, o *domain.ArbitrageOpportunity) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE arbitrage_opportunities
+		SET ended_at = $2, max_net_spread_bps = $3
+		WHERE id = $1`,
+		o.ID, o.EndedAt, o.MaxNetSpreadBps)
+	return err
+}
+
+// ListOpportunities returns opportunities detected in [from, to), newest first.
+func ListOpportunities(ctx context.Context, db *sql.DB, symbol string, from, to time.Time, limit int) ([]domain.ArbitrageOpportunity, error) {
+	query := `
+		SELECT id, symbol, buy_exchange, sell_exchange, buy_price, sell_price,
+			spread_bps, net_spread_bps, max_net_spread_bps, started_at, detected_at, ended_at
+		FROM arbitrage_opportunities
+		WHERE detected_at >= $1 AND detected_at < $2`
+	args := []interface{}{from, to}
+	if symbol != "" {
+		query += ` AND symbol = $3`
+		args = append(args, symbol)
+	}
+	query += ` ORDER BY detected_at DESC LIMIT ` + strconv.Itoa(limit)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []domain.ArbitrageOpportunity
+	for rows.Next() {
+		var o domain.ArbitrageOpportunity
+		var endedAt sql.NullTime
+		if err := rows.Scan(&o.ID, &o.Symbol, &o.BuyExchange, &o.SellExchange, &o.BuyPrice, &o.SellPrice,
+			&o.SpreadBps, &o.NetSpreadBps, &o.MaxNetSpreadBps, &o.StartedAt, &o.DetectedAt, &endedAt); err != nil {
+			return nil, err
+		}
+		if endedAt.Valid {
+			o.EndedAt = &endedAt.Time
+		}
+		result = append(result, o)
+	}
+
+	return result, rows.Err()
+}
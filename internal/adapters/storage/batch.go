@@ -3,14 +3,29 @@ package storage
 import (
 	"database/sql"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"marketflow/internal/domain"
 )
 
-func SaveBatchToPostgres(toPG <-chan domain.PriceUpdate, db *sql.DB, logger *slog.Logger) {
-	const batchSize = 100
-	ticker := time.NewTicker(1 * time.Second)
+var lastFlush atomic.Int64
+
+func markFlushed() {
+	lastFlush.Store(time.Now().UnixNano())
+}
+
+// LastFlush is when a tick batch was last committed, zero if none was yet.
+func LastFlush() time.Time {
+	n := lastFlush.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+func SaveBatchToPostgres(toPG <-chan domain.PriceUpdate, db *sql.DB, batchSize int, flushInterval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(flushInterval)
 	defer ticker.Stop()
 
 	var rawBatch []domain.PriceUpdate
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+const rawCandlesQuery = `
+	SELECT date_bin($1::interval, timestamp, $2) AS bucket,
+		(array_agg(price ORDER BY timestamp ASC))[1],
+		MAX(price),
+		MIN(price),
+		(array_agg(price ORDER BY timestamp DESC))[1],
+		AVG(price),
+		COUNT(*)
+	FROM price_raw
+	WHERE symbol = $3 AND timestamp >= $2 AND timestamp < $4`
+
+const aggCandlesQuery = `
+	SELECT date_bin($1::interval, timestamp, $2) AS bucket,
+		(array_agg(average_price ORDER BY timestamp ASC))[1],
+		MAX(max_price),
+		MIN(min_price),
+		(array_agg(average_price ORDER BY timestamp DESC))[1],
+		AVG(average_price),
+		0
+	FROM aggregated_prices
+	WHERE symbol = $3 AND timestamp >= $2 AND timestamp < $4`
+
+// QueryCandles builds OHLC bars of the given interval over [from, to).
+// Bars come from price_raw; buckets with no raw ticks left are filled from
+// aggregated_prices, in which case open/close are the first/last minute
+// averages and Count is 0. An empty exchange consolidates all exchanges.
+func QueryCandles(ctx context.Context, db *sql.DB, symbol, exchange string, interval time.Duration, from, to time.Time) ([]domain.Candle, error) {
+	raw, err := queryCandles(ctx, db, rawCandlesQuery, symbol, exchange, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	agg, err := queryCandles(ctx, db, aggCandlesQuery, symbol, exchange, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge both sorted series, raw bars win on the same bucket
+	candles := make([]domain.Candle, 0, len(raw)+len(agg))
+	i, j := 0, 0
+	for i < len(raw) || j < len(agg) {
+		switch {
+		case j >= len(agg) || (i < len(raw) && raw[i].Start.Before(agg[j].Start)):
+			candles = append(candles, raw[i])
+			i++
+		case i >= len(raw) || agg[j].Start.Before(raw[i].Start):
+			candles = append(candles, agg[j])
+			j++
+		default:
+			candles = append(candles, raw[i])
+			i++
+			j++
+		}
+	}
+
+	return candles, nil
+}
+
+func queryCandles(ctx context.Context, db *sql.DB, query, symbol, exchange string, interval time.Duration, from, to time.Time) ([]domain.Candle, error) {
+	args := []interface{}{
+		fmt.Sprintf("%d seconds", int64(interval.Seconds())),
+		from,
+		symbol,
+		to,
+	}
+	if exchange != "" {
+		query += " AND exchange = $5"
+		args = append(args, exchange)
+	}
+	query += " GROUP BY bucket ORDER BY bucket"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candles []domain.Candle
+	for rows.Next() {
+		c := domain.Candle{
+			Exchange: exchange,
+			Symbol:   symbol,
+			Interval: interval,
+		}
+		if err := rows.Scan(&c.Start, &c.Open, &c.High, &c.Low, &c.Close, &c.Avg, &c.Count); err != nil {
+			return nil, err
+		}
+		c.Start = c.Start.UTC()
+		candles = append(candles, c)
+	}
+
+	return candles, rows.Err()
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// BarClose is the closing average price of one interval bucket.
+type BarClose struct {
+	Symbol string
+	Start  time.Time
+	Close  float64
+}
+
+// The inner query takes the last aggregate of each exchange per bucket; the
+// outer one averages those across exchanges when no exchange is given.
+const barClosesQuery = `
+	SELECT symbol, bucket, AVG(close)
+	FROM (
+		SELECT symbol, exchange,
+			date_bin($1::interval, timestamp, $2) AS bucket,
+			(array_agg(average_price ORDER BY timestamp DESC))[1] AS close
+		FROM aggregated_prices
+		WHERE symbol = ANY($3) AND timestamp >= $2 AND timestamp < $4 %s
+		GROUP BY symbol, exchange, bucket
+	) t
+	WHERE close > 0
+	GROUP BY symbol, bucket
+	ORDER BY symbol, bucket`
+
+// QueryBarCloses returns per-symbol bar closes of the given interval over
+// [from, to), ordered by symbol and bucket.
+func QueryBarCloses(ctx context.Context, db *sql.DB, symbols []string, exchange string, interval time.Duration, from, to time.Time) ([]BarClose, error) {
+	args := []interface{}{
+		fmt.Sprintf("%d seconds", int64(interval.Seconds())),
+		from,
+		pq.Array(symbols),
+		to,
+	}
+	filter := ""
+	if exchange != "" {
+		filter = "AND exchange = $5"
+		args = append(args, exchange)
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(barClosesQuery, filter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []BarClose
+	for rows.Next() {
+		var b BarClose
+		if err := rows.Scan(&b.Symbol, &b.Start, &b.Close); err != nil {
+			return nil, err
+		}
+		b.Start = b.Start.UTC()
+		result = append(result, b)
+	}
+
+	return result, rows.Err()
+}
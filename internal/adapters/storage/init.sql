@@ -16,9 +16,57 @@ CREATE TABLE IF NOT EXISTS price_raw (
     timestamp TIMESTAMPTZ NOT NULL
 );
 
+ALTER TABLE price_raw ADD COLUMN IF NOT EXISTS quantity DOUBLE PRECISION;
+
 CREATE INDEX IF NOT EXISTS idx_aggregated_prices_symbol_exchange ON aggregated_prices(symbol, exchange);
 CREATE INDEX IF NOT EXISTS idx_aggregated_prices_timestamp ON aggregated_prices(timestamp);
 CREATE INDEX IF NOT EXISTS idx_price_raw_symbol_exchange ON price_raw(symbol, exchange);
 CREATE INDEX IF NOT EXISTS idx_price_raw_timestamp ON price_raw(timestamp);
 CREATE INDEX IF NOT EXISTS idx_price_raw_symbol_timestamp ON price_raw(symbol, timestamp DESC);
-CREATE INDEX IF NOT EXISTS idx_aggregated_prices_symbol_timestamp ON aggregated_prices(symbol, timestamp DESC);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_aggregated_prices_symbol_timestamp ON aggregated_prices(symbol, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_price_raw_exchange_symbol_ts_id ON price_raw(exchange, symbol, timestamp, id);
+
+CREATE TABLE IF NOT EXISTS arbitrage_opportunities (
+    id SERIAL PRIMARY KEY,
+    symbol TEXT NOT NULL,
+    buy_exchange TEXT NOT NULL,
+    sell_exchange TEXT NOT NULL,
+    buy_price DOUBLE PRECISION NOT NULL,
+    sell_price DOUBLE PRECISION NOT NULL,
+    spread_bps DOUBLE PRECISION NOT NULL,
+    net_spread_bps DOUBLE PRECISION NOT NULL,
+    max_net_spread_bps DOUBLE PRECISION NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    detected_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_arbitrage_symbol_detected ON arbitrage_opportunities(symbol, detected_at DESC);
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id SERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    exchange TEXT NOT NULL DEFAULT '',
+    level DOUBLE PRECISION NOT NULL DEFAULT 0,
+    direction TEXT NOT NULL DEFAULT '',
+    percent DOUBLE PRECISION NOT NULL DEFAULT 0,
+    window_seconds INTEGER NOT NULL DEFAULT 0,
+    spread_bps DOUBLE PRECISION NOT NULL DEFAULT 0,
+    stale_seconds INTEGER NOT NULL DEFAULT 0,
+    webhook_url TEXT NOT NULL,
+    secret TEXT NOT NULL DEFAULT '',
+    cooldown_seconds INTEGER NOT NULL DEFAULT 60,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS price_quarantine (
+    id SERIAL PRIMARY KEY,
+    symbol TEXT NOT NULL,
+    exchange TEXT NOT NULL,
+    price DOUBLE PRECISION,
+    timestamp TIMESTAMPTZ NOT NULL,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_quarantine_timestamp ON price_quarantine(timestamp);
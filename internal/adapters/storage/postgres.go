@@ -8,6 +8,13 @@ import (
 	"time"
 
 	"marketflow/internal/domain"
+	"marketflow/pkg/metrics"
+)
+
+var (
+	batchRows     = metrics.NewCounterVec("marketflow_db_batch_rows_total", "Rows committed by batch inserts.", "table")
+	batchSeconds  = metrics.NewHistogramVec("marketflow_db_batch_duration_seconds", "Batch insert duration from begin to commit.", nil, "table")
+	batchFailures = metrics.NewCounterVec("marketflow_db_batch_failures_total", "Batch inserts that were rolled back or failed to commit.", "table")
 )
 
 type PostgresClient struct {
@@ -26,10 +33,12 @@ func insertAggBatch(db *sql.DB, batch []domain.PriceUpdate, logger *slog.Logger)
 	if len(batch) == 0 {
 		return
 	}
+	defer batchSeconds.With("aggregated_prices").ObserveSince(time.Now())
 
 	tx, err := db.Begin()
 	if err != nil {
 		logger.Error("Failed to begin transaction", "error", err)
+		batchFailures.With("aggregated_prices").Inc()
 		return
 	}
 
@@ -40,6 +49,7 @@ func insertAggBatch(db *sql.DB, batch []domain.PriceUpdate, logger *slog.Logger)
 	if err != nil {
 		logger.Error("Failed to prepare statement", "error", err)
 		_ = tx.Rollback()
+		batchFailures.With("aggregated_prices").Inc()
 		return
 	}
 	defer stmt.Close()
@@ -49,13 +59,17 @@ func insertAggBatch(db *sql.DB, batch []domain.PriceUpdate, logger *slog.Logger)
 		if err != nil {
 			logger.Error("Insert failed", "error", err)
 			_ = tx.Rollback()
+			batchFailures.With("aggregated_prices").Inc()
 			return
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
 		logger.Error("Failed to commit transaction", "error", err)
+		batchFailures.With("aggregated_prices").Inc()
 	} else {
+		markFlushed()
+		batchRows.With("aggregated_prices").Add(float64(len(batch)))
 		logger.Debug("Inserted aggregated batch", "count", len(batch))
 	}
 }
@@ -64,31 +78,45 @@ func insertRawBatch(db *sql.DB, batch []domain.PriceUpdate, logger *slog.Logger)
 	if len(batch) == 0 {
 		return
 	}
+	defer batchSeconds.With("price_raw").ObserveSince(time.Now())
 
 	tx, err := db.Begin()
 	if err != nil {
 		logger.Error("Failed to begin transaction", "error", err)
+		batchFailures.With("price_raw").Inc()
 		return
 	}
-	defer tx.Commit()
 
 	stmt, err := tx.Prepare(`
-		INSERT INTO price_raw (symbol, exchange, price, timestamp)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO price_raw (symbol, exchange, price, quantity, timestamp)
+		VALUES ($1, $2, $3, $4, $5)
 	`)
 	if err != nil {
 		logger.Error("Failed to prepare statement", "error", err)
+		_ = tx.Rollback()
+		batchFailures.With("price_raw").Inc()
 		return
 	}
 	defer stmt.Close()
 
 	for _, update := range batch {
-		_, err := stmt.Exec(update.Symbol, update.Exchange, update.Price, update.ReceivedAt)
+		var quantity sql.NullFloat64
+		if update.Quantity > 0 {
+			quantity = sql.NullFloat64{Float64: update.Quantity, Valid: true}
+		}
+		_, err := stmt.Exec(update.Symbol, update.Exchange, update.Price, quantity, update.ReceivedAt)
 		if err != nil {
 			logger.Error("Insert failed", "error", err)
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		batchFailures.With("price_raw").Inc()
+		return
+	}
+	markFlushed()
+	batchRows.With("price_raw").Add(float64(len(batch)))
 	logger.Debug("Inserted raw batch", "count", len(batch))
 }
 
@@ -107,11 +135,12 @@ func (pc *PostgresClient) GetHighest(symbol string, exchange *string, duration *
 		argIndex++
 	}
 	if duration != nil {
-		query += ` AND timestamp >= NOW() - INTERVAL '` + duration.String() + `'`
+		query += ` AND timestamp >= $` + strconv.Itoa(argIndex)
+		args = append(args, time.Now().Add(-*duration))
 	}
 	query += ` ORDER BY max_price DESC LIMIT 1`
 
-	row := pc.DB.QueryRow(query, args)
+	row := pc.DB.QueryRow(query, args...)
 
 	var result domain.PriceUpdate
 	var ts time.Time
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"database/sql"
+	"log/slog"
+	"math"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// SaveQuarantine batches rejected ticks into price_quarantine.
+func SaveQuarantine(in <-chan domain.QuarantinedTick, db *sql.DB, logger *slog.Logger) {
+	const batchSize = 100
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	var batch []domain.QuarantinedTick
+	for {
+		select {
+		case tick, ok := <-in:
+			if !ok {
+				insertQuarantineBatch(db, batch, logger)
+				return
+			}
+			batch = append(batch, tick)
+			if len(batch) >= batchSize {
+				insertQuarantineBatch(db, batch, logger)
+				batch = nil
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				insertQuarantineBatch(db, batch, logger)
+				batch = nil
+			}
+		}
+	}
+}
+
+func insertQuarantineBatch(db *sql.DB, batch []domain.QuarantinedTick, logger *slog.Logger) {
+	if len(batch) == 0 {
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO price_quarantine (symbol, exchange, price, timestamp, reason)
+		VALUES ($1, $2, $3, $4, $5)
+	`)
+	if err != nil {
+		logger.Error("Failed to prepare statement", "error", err)
+		_ = tx.Rollback()
+		return
+	}
+	defer stmt.Close()
+
+	for _, q := range batch {
+		// NaN and Inf cannot be stored as numbers
+		var price sql.NullFloat64
+		if !math.IsNaN(q.Update.Price) && !math.IsInf(q.Update.Price, 0) {
+			price = sql.NullFloat64{Float64: q.Update.Price, Valid: true}
+		}
+		if _, err := stmt.Exec(q.Update.Symbol, q.Update.Exchange, price, q.Update.ReceivedAt, q.Reason); err != nil {
+			logger.Error("Insert failed", "error", err)
+			_ = tx.Rollback()
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+	}
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+// RawTick is a row of price_raw.
+type RawTick struct {
+	ID        int64
+	Exchange  string
+	Symbol    string
+	Price     float64
+	Timestamp time.Time
+}
+
+// TickCursor points just past the last row returned, keyset style.
+type TickCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+// ScanTicks calls fn for every tick of exchange/symbol in [from, to) after the
+// cursor, ordered by (timestamp, id). limit <= 0 means no limit.
+func ScanTicks(ctx context.Context, db *sql.DB, exchange, symbol string, from, to time.Time, after *TickCursor, limit int, fn func(RawTick) error) error {
+	query := `
+		SELECT id, exchange, symbol, price, timestamp
+		FROM price_raw
+		WHERE exchange = $1 AND symbol = $2 AND timestamp >= $3 AND timestamp < $4`
+	args := []interface{}{exchange, symbol, from, to}
+
+	if after != nil {
+		query += ` AND (timestamp, id) > ($5, $6)`
+		args = append(args, after.Timestamp, after.ID)
+	}
+	query += ` ORDER BY timestamp, id`
+	if limit > 0 {
+		query += ` LIMIT ` + strconv.Itoa(limit)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t RawTick
+		if err := rows.Scan(&t.ID, &t.Exchange, &t.Symbol, &t.Price, &t.Timestamp); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
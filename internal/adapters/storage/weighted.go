@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// WeightedPrice is a weighted average for one exchange. Weight is seconds
+// for TWAP and traded quantity for VWAP.
+type WeightedPrice struct {
+	Exchange string
+	Value    float64
+	Weight   float64
+	Count    int
+	LastAt   time.Time
+}
+
+// Each tick's price holds until the next tick of the same exchange; the
+// last one holds until the end of the range.
+const twapQuery = `
+	SELECT exchange,
+		SUM(price * EXTRACT(EPOCH FROM (next_ts - timestamp))) / NULLIF(SUM(EXTRACT(EPOCH FROM (next_ts - timestamp))), 0),
+		COALESCE(SUM(EXTRACT(EPOCH FROM (next_ts - timestamp))), 0),
+		COUNT(*),
+		MAX(timestamp)
+	FROM (
+		SELECT exchange, price, timestamp,
+			COALESCE(LEAD(timestamp) OVER (PARTITION BY exchange ORDER BY timestamp), $3) AS next_ts
+		FROM price_raw
+		WHERE symbol = $1 AND timestamp >= $2 AND timestamp < $3 %s
+	) t
+	GROUP BY exchange
+	HAVING SUM(EXTRACT(EPOCH FROM (next_ts - timestamp))) > 0
+	ORDER BY exchange`
+
+const vwapQuery = `
+	SELECT exchange,
+		SUM(price * quantity) / SUM(quantity),
+		SUM(quantity),
+		COUNT(*),
+		MAX(timestamp)
+	FROM price_raw
+	WHERE symbol = $1 AND timestamp >= $2 AND timestamp < $3 AND quantity > 0 %s
+	GROUP BY exchange
+	ORDER BY exchange`
+
+// QueryTWAP returns the time-weighted average price per exchange over [from, to).
+func QueryTWAP(ctx context.Context, db *sql.DB, symbol, exchange string, from, to time.Time) ([]WeightedPrice, error) {
+	return queryWeighted(ctx, db, twapQuery, symbol, exchange, from, to)
+}
+
+// QueryVWAP returns the volume-weighted average price per exchange over
+// [from, to), using only ticks that carried a quantity.
+func QueryVWAP(ctx context.Context, db *sql.DB, symbol, exchange string, from, to time.Time) ([]WeightedPrice, error) {
+	return queryWeighted(ctx, db, vwapQuery, symbol, exchange, from, to)
+}
+
+func queryWeighted(ctx context.Context, db *sql.DB, query, symbol, exchange string, from, to time.Time) ([]WeightedPrice, error) {
+	args := []interface{}{symbol, from, to}
+	filter := ""
+	if exchange != "" {
+		filter = "AND exchange = $4"
+		args = append(args, exchange)
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(query, filter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []WeightedPrice
+	for rows.Next() {
+		var wp WeightedPrice
+		if err := rows.Scan(&wp.Exchange, &wp.Value, &wp.Weight, &wp.Count, &wp.LastAt); err != nil {
+			return nil, err
+		}
+		result = append(result, wp)
+	}
+
+	return result, rows.Err()
+}
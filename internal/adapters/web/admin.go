@@ -0,0 +1,80 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"marketflow/internal/config"
+)
+
+type ChangeView struct {
+	Path            string `json:"path"`
+	Old             string `json:"old"`
+	New             string `json:"new"`
+	RestartRequired bool   `json:"restart_required"`
+}
+
+type ReloadView struct {
+	At      string       `json:"at"`
+	Trigger string       `json:"trigger"`
+	Status  string       `json:"status"`
+	Changes []ChangeView `json:"changes"`
+	Error   string       `json:"error,omitempty"`
+}
+
+type AdminConfigResponse struct {
+	Config         *config.Config `json:"config"`
+	RestartPending []ChangeView   `json:"restart_pending"`
+	Reloads        []ReloadView   `json:"reloads"`
+}
+
+func toChangeViews(changes []config.Change) []ChangeView {
+	out := make([]ChangeView, len(changes))
+	for i, ch := range changes {
+		out[i] = ChangeView{Path: ch.Path, Old: ch.Old, New: ch.New, RestartRequired: ch.Restart}
+	}
+	return out
+}
+
+func toReloadView(res config.ReloadResult) ReloadView {
+	view := ReloadView{
+		At:      res.At.UTC().Format(time.RFC3339Nano),
+		Trigger: res.Trigger,
+		Status:  res.Status,
+		Changes: toChangeViews(res.Changes),
+	}
+	if res.Err != nil {
+		view.Error = res.Err.Error()
+	}
+	return view
+}
+
+// HandleAdminConfig serves GET /admin/config: the running config with
+// secrets masked, settings waiting for a restart and recent reloads.
+func HandleAdminConfig(reloader *config.Reloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		history := reloader.History()
+		resp := AdminConfigResponse{
+			Config:         reloader.Current().Redacted(),
+			RestartPending: toChangeViews(reloader.PendingRestart()),
+			Reloads:        make([]ReloadView, len(history)),
+		}
+		for i, res := range history {
+			resp.Reloads[i] = toReloadView(res)
+		}
+		writeJSONResponse(w, http.StatusOK, resp)
+	}
+}
+
+// HandleAdminReload serves POST /admin/config/reload. A config that fails
+// validation is answered with 422 and leaves the running one in place.
+func HandleAdminReload(reloader *config.Reloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := reloader.Reload("admin")
+		status := http.StatusOK
+		if res.Status == config.ReloadRejected {
+			status = http.StatusUnprocessableEntity
+		}
+		writeJSONResponse(w, status, toReloadView(res))
+	}
+}
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/alerts"
+	"marketflow/internal/domain"
+)
+
+const defaultAlertCooldown = 60
+
+type AlertRuleRequest struct {
+	Type            string  `json:"type"`
+	Symbol          string  `json:"symbol"`
+	Exchange        string  `json:"exchange"`
+	Level           float64 `json:"level"`
+	Direction       string  `json:"direction"`
+	Percent         float64 `json:"percent"`
+	WindowSeconds   int     `json:"window_seconds"`
+	SpreadBps       float64 `json:"spread_bps"`
+	StaleSeconds    int     `json:"stale_seconds"`
+	WebhookURL      string  `json:"webhook_url"`
+	Secret          string  `json:"secret"`
+	CooldownSeconds *int    `json:"cooldown_seconds"`
+}
+
+// AlertRuleResponse never echoes the webhook secret back.
+type AlertRuleResponse struct {
+	ID              int64   `json:"id"`
+	Type            string  `json:"type"`
+	Symbol          string  `json:"symbol"`
+	Exchange        string  `json:"exchange,omitempty"`
+	Level           float64 `json:"level,omitempty"`
+	Direction       string  `json:"direction,omitempty"`
+	Percent         float64 `json:"percent,omitempty"`
+	WindowSeconds   int     `json:"window_seconds,omitempty"`
+	SpreadBps       float64 `json:"spread_bps,omitempty"`
+	StaleSeconds    int     `json:"stale_seconds,omitempty"`
+	WebhookURL      string  `json:"webhook_url"`
+	Signed          bool    `json:"signed"`
+	CooldownSeconds int     `json:"cooldown_seconds"`
+	CreatedAt       string  `json:"created_at"`
+}
+
+func toAlertRuleResponse(r domain.AlertRule) AlertRuleResponse {
+	return AlertRuleResponse{
+		ID:              r.ID,
+		Type:            r.Type,
+		Symbol:          r.Symbol,
+		Exchange:        r.Exchange,
+		Level:           r.Level,
+		Direction:       r.Direction,
+		Percent:         r.Percent,
+		WindowSeconds:   int(r.Window.Seconds()),
+		SpreadBps:       r.SpreadBps,
+		StaleSeconds:    int(r.StaleAfter.Seconds()),
+		WebhookURL:      r.WebhookURL,
+		Signed:          r.Secret != "",
+		CooldownSeconds: int(r.Cooldown.Seconds()),
+		CreatedAt:       r.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func HandleCreateAlert(engine *alerts.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AlertRuleRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+
+		cooldown := defaultAlertCooldown
+		if req.CooldownSeconds != nil {
+			cooldown = *req.CooldownSeconds
+		}
+
+		rule := &domain.AlertRule{
+			Type:       req.Type,
+			Symbol:     req.Symbol,
+			Exchange:   req.Exchange,
+			Level:      req.Level,
+			Direction:  req.Direction,
+			Percent:    req.Percent,
+			Window:     time.Duration(req.WindowSeconds) * time.Second,
+			SpreadBps:  req.SpreadBps,
+			StaleAfter: time.Duration(req.StaleSeconds) * time.Second,
+			WebhookURL: req.WebhookURL,
+			Secret:     req.Secret,
+			Cooldown:   time.Duration(cooldown) * time.Second,
+		}
+		if err := rule.Validate(); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		if err := engine.Add(ctx, rule); err != nil {
+			slog.Error("Failed to create alert rule", "error", err)
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+
+		writeJSONResponse(w, http.StatusCreated, toAlertRuleResponse(*rule))
+	}
+}
+
+func HandleListAlerts(engine *alerts.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules := engine.Rules()
+		resp := make([]AlertRuleResponse, 0, len(rules))
+		for _, rule := range rules {
+			resp = append(resp, toAlertRuleResponse(rule))
+		}
+		writeJSONResponse(w, http.StatusOK, resp)
+	}
+}
+
+func HandleDeleteAlert(engine *alerts.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		found, err := engine.Remove(ctx, id)
+		if err != nil {
+			slog.Error("Failed to delete alert rule", "error", err)
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		if !found {
+			writeJSONError(w, http.StatusNotFound, "alert rule not found")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,161 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/adapters/storage"
+	"marketflow/internal/arbitrage"
+	"marketflow/internal/domain"
+	"marketflow/internal/stream"
+)
+
+type OpportunityView struct {
+	ID              int64   `json:"id,omitempty"`
+	Symbol          string  `json:"symbol"`
+	BuyExchange     string  `json:"buy_exchange"`
+	SellExchange    string  `json:"sell_exchange"`
+	BuyPrice        float64 `json:"buy_price"`
+	SellPrice       float64 `json:"sell_price"`
+	SpreadBps       float64 `json:"spread_bps"`
+	NetSpreadBps    float64 `json:"net_spread_bps"`
+	MaxNetSpreadBps float64 `json:"max_net_spread_bps"`
+	StartedAt       string  `json:"started_at"`
+	DetectedAt      string  `json:"detected_at"`
+	EndedAt         string  `json:"ended_at,omitempty"`
+}
+
+type ArbitrageResponse struct {
+	Active  []OpportunityView `json:"active"`
+	History []OpportunityView `json:"history,omitempty"`
+}
+
+func toOpportunityView(o domain.ArbitrageOpportunity) OpportunityView {
+	v := OpportunityView{
+		ID:              o.ID,
+		Symbol:          o.Symbol,
+		BuyExchange:     o.BuyExchange,
+		SellExchange:    o.SellExchange,
+		BuyPrice:        o.BuyPrice,
+		SellPrice:       o.SellPrice,
+		SpreadBps:       o.SpreadBps,
+		NetSpreadBps:    o.NetSpreadBps,
+		MaxNetSpreadBps: o.MaxNetSpreadBps,
+		StartedAt:       o.StartedAt.UTC().Format(time.RFC3339Nano),
+		DetectedAt:      o.DetectedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if o.EndedAt != nil {
+		v.EndedAt = o.EndedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+// HandleArbitrage serves GET /arbitrage?symbol=&active=true&period=&from=&to=&limit=
+func HandleArbitrage(detector *arbitrage.Detector, db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		symbol := query.Get("symbol")
+
+		resp := ArbitrageResponse{Active: []OpportunityView{}}
+		for _, o := range detector.Active() {
+			if symbol == "" || o.Symbol == symbol {
+				resp.Active = append(resp.Active, toOpportunityView(o))
+			}
+		}
+
+		if query.Get("active") == "true" {
+			writeJSONResponse(w, http.StatusOK, resp)
+			return
+		}
+
+		period := query.Get("period")
+		if period == "" && query.Get("from") == "" {
+			period = "1d"
+		}
+		timeRange, err := domain.ParseRange(period, query.Get("from"), query.Get("to"), time.Now())
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		limit := 100
+		if l := query.Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n <= 0 || n > 1000 {
+				writeJSONError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+				return
+			}
+			limit = n
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		history, err := storage.ListOpportunities(ctx, db, symbol, timeRange.From, timeRange.To, limit)
+		if err != nil {
+			slog.Error("Arbitrage query failed", "error", err)
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		resp.History = make([]OpportunityView, 0, len(history))
+		for _, o := range history {
+			resp.History = append(resp.History, toOpportunityView(o))
+		}
+
+		writeJSONResponse(w, http.StatusOK, resp)
+	}
+}
+
+// HandleStreamArbitrage pushes opportunity open and close events as SSE.
+func HandleStreamArbitrage(hub *stream.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lastID, err := parseLastEventID(r)
+		if err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		filter := eventFilter(parseList(r.URL.Query().Get("symbols")), nil, "arbitrage")
+		sub := hub.Subscribe(lastID, sseClientBuffer, filter)
+		defer hub.Unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		rc.Flush()
+
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				o, _ := ev.Payload.(domain.ArbitrageOpportunity)
+				if err := writeSSE(w, ev.ID, ev.Type, toOpportunityView(o)); err != nil {
+					return
+				}
+				rc.Flush()
+			case <-keepAlive.C:
+				if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+					return
+				}
+				rc.Flush()
+			}
+		}
+	}
+}
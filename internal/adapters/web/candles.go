@@ -0,0 +1,167 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/adapters/storage"
+	"marketflow/internal/domain"
+)
+
+const (
+	defaultCandlePoints = 120
+	maxCandlePoints     = 5000
+)
+
+var candleIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+}
+
+// CandlesResponse is column oriented so charting libraries can consume it directly.
+type CandlesResponse struct {
+	Symbol   string    `json:"symbol"`
+	Exchange string    `json:"exchange,omitempty"`
+	Interval string    `json:"interval"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Time     []int64   `json:"time"`
+	Open     []float64 `json:"open"`
+	High     []float64 `json:"high"`
+	Low      []float64 `json:"low"`
+	Close    []float64 `json:"close"`
+	Avg      []float64 `json:"avg"`
+	Count    []int     `json:"count"`
+}
+
+// HandleCandles serves GET /candles/{symbol}?exchange=&interval=&from=&to=&period=&fill=&limit=
+func HandleCandles(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		symbol := r.PathValue("symbol")
+		exchange := query.Get("exchange")
+
+		intervalName := query.Get("interval")
+		if intervalName == "" {
+			intervalName = "1m"
+		}
+		interval, ok := candleIntervals[intervalName]
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "interval must be one of 1m, 5m, 15m, 1h")
+			return
+		}
+
+		limit := defaultCandlePoints
+		if l := query.Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n <= 0 || n > maxCandlePoints {
+				writeJSONError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxCandlePoints))
+				return
+			}
+			limit = n
+		}
+
+		timeRange, err := domain.ParseRange(query.Get("period"), query.Get("from"), query.Get("to"), time.Now())
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		to, from := timeRange.To, timeRange.From
+		if from.IsZero() {
+			from = to.Add(-time.Duration(limit) * interval)
+		}
+		from = from.Truncate(interval)
+
+		if !from.Before(to) {
+			writeJSONError(w, http.StatusBadRequest, "from must be before to")
+			return
+		}
+		if points := int(to.Sub(from) / interval); points > limit {
+			writeJSONError(w, http.StatusBadRequest, "range has "+strconv.Itoa(points)+" points, more than limit "+strconv.Itoa(limit)+"; use a larger interval")
+			return
+		}
+
+		fill := query.Get("fill")
+		if fill != "" && fill != "none" && fill != "previous" {
+			writeJSONError(w, http.StatusBadRequest, "fill must be none or previous")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+
+		candles, err := storage.QueryCandles(ctx, db, symbol, exchange, interval, from, to)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+
+		if fill == "previous" {
+			candles = fillCandleGaps(candles, from, to, interval)
+		}
+
+		resp := CandlesResponse{
+			Symbol:   symbol,
+			Exchange: exchange,
+			Interval: intervalName,
+			From:     from.Format(time.RFC3339),
+			To:       to.Format(time.RFC3339),
+			Time:     make([]int64, 0, len(candles)),
+			Open:     make([]float64, 0, len(candles)),
+			High:     make([]float64, 0, len(candles)),
+			Low:      make([]float64, 0, len(candles)),
+			Close:    make([]float64, 0, len(candles)),
+			Avg:      make([]float64, 0, len(candles)),
+			Count:    make([]int, 0, len(candles)),
+		}
+		for _, c := range candles {
+			resp.Time = append(resp.Time, c.Start.Unix())
+			resp.Open = append(resp.Open, c.Open)
+			resp.High = append(resp.High, c.High)
+			resp.Low = append(resp.Low, c.Low)
+			resp.Close = append(resp.Close, c.Close)
+			resp.Avg = append(resp.Avg, c.Avg)
+			resp.Count = append(resp.Count, c.Count)
+		}
+
+		writeJSONResponse(w, http.StatusOK, resp)
+	}
+}
+
+// fillCandleGaps inserts flat bars at the previous close for empty buckets.
+// Buckets before the first bar stay empty since there is nothing to carry.
+func fillCandleGaps(candles []domain.Candle, from, to time.Time, interval time.Duration) []domain.Candle {
+	if len(candles) == 0 {
+		return candles
+	}
+
+	filled := make([]domain.Candle, 0, int(to.Sub(from)/interval)+1)
+	prev := candles[0]
+	i := 0
+	for start := candles[0].Start; start.Before(to); start = start.Add(interval) {
+		if i < len(candles) && candles[i].Start.Equal(start) {
+			prev = candles[i]
+			filled = append(filled, prev)
+			i++
+			continue
+		}
+		filled = append(filled, domain.Candle{
+			Exchange: prev.Exchange,
+			Symbol:   prev.Symbol,
+			Start:    start,
+			Interval: interval,
+			Open:     prev.Close,
+			High:     prev.Close,
+			Low:      prev.Close,
+			Close:    prev.Close,
+			Avg:      prev.Close,
+		})
+	}
+
+	return filled
+}
@@ -0,0 +1,143 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/http"
+	"time"
+
+	"marketflow/internal/adapters/cache"
+	"marketflow/internal/domain"
+)
+
+const defaultMaxQuoteAge = 5 * time.Second
+
+type VenueQuote struct {
+	Exchange  string  `json:"exchange"`
+	Price     float64 `json:"price"`
+	Timestamp string  `json:"timestamp"`
+	AgeMs     int64   `json:"age_ms"`
+	Stale     bool    `json:"stale"`
+}
+
+// ConsolidatedResponse compares the latest price across venues. Best is the
+// venue quoting the highest price (best to sell into), worst the lowest.
+// Spread and mid only use quotes younger than max_age.
+type ConsolidatedResponse struct {
+	Symbol      string       `json:"symbol"`
+	Source      string       `json:"source"`
+	MaxAgeMs    int64        `json:"max_age_ms"`
+	Venues      []VenueQuote `json:"venues"`
+	BestVenue   string       `json:"best_venue,omitempty"`
+	WorstVenue  string       `json:"worst_venue,omitempty"`
+	Spread      float64      `json:"spread"`
+	SpreadBps   float64      `json:"spread_bps"`
+	MidPrice    float64      `json:"mid_price,omitempty"`
+	FreshVenues int          `json:"fresh_venues"`
+}
+
+func queryLatestPrices(ctx context.Context, db *sql.DB, symbol string) ([]cache.LatestPrice, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT ON (exchange) exchange, price, timestamp
+		FROM price_raw
+		WHERE symbol = $1 AND timestamp > now() - interval '1 hour'
+		ORDER BY exchange, timestamp DESC`, symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []cache.LatestPrice
+	for rows.Next() {
+		lp := cache.LatestPrice{Symbol: symbol}
+		if err := rows.Scan(&lp.Exchange, &lp.Price, &lp.Timestamp); err != nil {
+			return nil, err
+		}
+		prices = append(prices, lp)
+	}
+	return prices, rows.Err()
+}
+
+// HandleConsolidated serves GET /prices/consolidated/{symbol}?max_age=5s
+func HandleConsolidated(redisClient *cache.RedisClient, db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbol := r.PathValue("symbol")
+
+		maxAge := defaultMaxQuoteAge
+		if v := r.URL.Query().Get("max_age"); v != "" {
+			period, err := domain.ParsePeriod(v)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, "invalid max_age: "+err.Error())
+				return
+			}
+			maxAge = period.Duration()
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 100*time.Millisecond)
+		defer cancel()
+
+		source := "redis"
+		prices, err := redisClient.GetLatestPrices(ctx, symbol)
+		if err != nil {
+			pgCtx, pgCancel := context.WithTimeout(r.Context(), 500*time.Millisecond)
+			defer pgCancel()
+
+			prices, err = queryLatestPrices(pgCtx, db, symbol)
+			if err != nil {
+				writeJSONError(w, http.StatusInternalServerError, "database error")
+				return
+			}
+			source = "postgres"
+		}
+		if len(prices) == 0 {
+			writeJSONError(w, http.StatusNotFound, "price not available")
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, consolidate(symbol, source, prices, maxAge, time.Now()))
+	}
+}
+
+func consolidate(symbol, source string, prices []cache.LatestPrice, maxAge time.Duration, now time.Time) ConsolidatedResponse {
+	resp := ConsolidatedResponse{
+		Symbol:   symbol,
+		Source:   source,
+		MaxAgeMs: maxAge.Milliseconds(),
+		Venues:   make([]VenueQuote, 0, len(prices)),
+	}
+
+	high, low := math.Inf(-1), math.Inf(1)
+	for _, p := range prices {
+		age := now.Sub(p.Timestamp)
+		quote := VenueQuote{
+			Exchange:  p.Exchange,
+			Price:     p.Price,
+			Timestamp: p.Timestamp.UTC().Format(time.RFC3339Nano),
+			AgeMs:     age.Milliseconds(),
+			Stale:     age > maxAge,
+		}
+		resp.Venues = append(resp.Venues, quote)
+
+		if quote.Stale {
+			continue
+		}
+		resp.FreshVenues++
+		if p.Price > high {
+			high, resp.BestVenue = p.Price, p.Exchange
+		}
+		if p.Price < low {
+			low, resp.WorstVenue = p.Price, p.Exchange
+		}
+	}
+
+	if resp.FreshVenues > 0 {
+		resp.MidPrice = (high + low) / 2
+		resp.Spread = high - low
+		if resp.MidPrice > 0 {
+			resp.SpreadBps = resp.Spread / resp.MidPrice * 10000
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/adapters/storage"
+	"marketflow/internal/analytics"
+	"marketflow/internal/domain"
+)
+
+const (
+	defaultMinOverlap     = 30
+	maxCorrelationSymbols = 20
+)
+
+type CorrelationResponse struct {
+	Symbols    []string     `json:"symbols"`
+	Exchange   string       `json:"exchange"`
+	Window     string       `json:"window"`
+	Interval   string       `json:"interval"`
+	From       string       `json:"from"`
+	To         string       `json:"to"`
+	MinOverlap int          `json:"min_overlap"`
+	Returns    []int        `json:"returns"`
+	Matrix     [][]*float64 `json:"matrix"`
+	Overlap    [][]int      `json:"overlap"`
+}
+
+// HandleCorrelation serves GET /analytics/correlation?symbols=&exchange=&window=&to=&interval=&min_overlap=.
+// Without an exchange the bars are averaged across exchanges.
+func HandleCorrelation(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		exchange := query.Get("exchange")
+
+		var symbols []string
+		for _, s := range strings.Split(query.Get("symbols"), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				symbols = append(symbols, s)
+			}
+		}
+		if len(symbols) < 2 || len(symbols) > maxCorrelationSymbols {
+			writeJSONError(w, http.StatusBadRequest, "symbols must list between 2 and "+strconv.Itoa(maxCorrelationSymbols)+" symbols")
+			return
+		}
+		seen := make(map[string]bool, len(symbols))
+		for _, s := range symbols {
+			if seen[s] {
+				writeJSONError(w, http.StatusBadRequest, "duplicate symbol "+s)
+				return
+			}
+			seen[s] = true
+		}
+
+		intervalName := query.Get("interval")
+		if intervalName == "" {
+			intervalName = "1m"
+		}
+		interval, ok := candleIntervals[intervalName]
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "interval must be one of 1m, 5m, 15m, 1h")
+			return
+		}
+
+		window := query.Get("window")
+		if window == "" {
+			window = "1h"
+		}
+		timeRange, err := domain.ParseRange(window, "", query.Get("to"), time.Now())
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		from, to := timeRange.From.Truncate(interval), timeRange.To
+		if int(to.Sub(from)/interval) > maxCandlePoints {
+			writeJSONError(w, http.StatusBadRequest, "window holds more than "+strconv.Itoa(maxCandlePoints)+" intervals")
+			return
+		}
+
+		minOverlap := defaultMinOverlap
+		if v := query.Get("min_overlap"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 2 {
+				writeJSONError(w, http.StatusBadRequest, "min_overlap must be an integer of at least 2")
+				return
+			}
+			minOverlap = n
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		bars, err := storage.QueryBarCloses(ctx, db, symbols, exchange, interval, from, to)
+		if err != nil {
+			slog.Error("Correlation query failed", "error", err)
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+
+		starts := make(map[string][]time.Time, len(symbols))
+		closes := make(map[string][]float64, len(symbols))
+		for _, b := range bars {
+			starts[b.Symbol] = append(starts[b.Symbol], b.Start)
+			closes[b.Symbol] = append(closes[b.Symbol], b.Close)
+		}
+
+		series := make([]analytics.Returns, len(symbols))
+		counts := make([]int, len(symbols))
+		for i, s := range symbols {
+			series[i] = analytics.LogReturns(starts[s], closes[s], interval)
+			counts[i] = len(series[i])
+		}
+		matrix := analytics.Correlate(series, minOverlap)
+
+		source := exchange
+		if source == "" {
+			source = "consolidated"
+		}
+		writeJSONResponse(w, http.StatusOK, CorrelationResponse{
+			Symbols:    symbols,
+			Exchange:   source,
+			Window:     window,
+			Interval:   intervalName,
+			From:       from.UTC().Format(time.RFC3339),
+			To:         to.UTC().Format(time.RFC3339),
+			MinOverlap: minOverlap,
+			Returns:    counts,
+			Matrix:     matrix.Values,
+			Overlap:    matrix.Overlap,
+		})
+	}
+}
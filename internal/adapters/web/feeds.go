@@ -0,0 +1,124 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"marketflow/internal/feeds"
+)
+
+type FeedView struct {
+	Exchange     string   `json:"exchange"`
+	Symbol       string   `json:"symbol"`
+	Status       string   `json:"status"`
+	Reasons      []string `json:"reasons,omitempty"`
+	LastTick     string   `json:"last_tick,omitempty"`
+	AgeSeconds   float64  `json:"age_seconds"`
+	TickRate     float64  `json:"tick_rate"`
+	Ticks        int64    `json:"ticks"`
+	Rejected     int64    `json:"rejected"`
+	DecodeErrors int64    `json:"decode_errors"`
+	Gaps         int64    `json:"gaps"`
+	LastGap      string   `json:"last_gap,omitempty"`
+}
+
+type ExchangeView struct {
+	Name         string `json:"name"`
+	Connected    bool   `json:"connected"`
+	Connections  int    `json:"connections"`
+	Reconnects   int64  `json:"reconnects"`
+	DecodeErrors int64  `json:"decode_errors"`
+	LastConnect  string `json:"last_connect,omitempty"`
+	LastError    string `json:"last_error,omitempty"`
+}
+
+type FeedSummary struct {
+	Total    int      `json:"total"`
+	OK       int      `json:"ok"`
+	Degraded []string `json:"degraded"`
+	Stale    []string `json:"stale"`
+}
+
+type FeedsResponse struct {
+	Summary   FeedSummary    `json:"summary"`
+	Exchanges []ExchangeView `json:"exchanges"`
+	Feeds     []FeedView     `json:"feeds"`
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func toFeedSummary(s feeds.Summary) FeedSummary {
+	out := FeedSummary{Total: s.Total, OK: s.OK, Degraded: s.Degraded, Stale: s.Stale}
+	if out.Degraded == nil {
+		out.Degraded = []string{}
+	}
+	if out.Stale == nil {
+		out.Stale = []string{}
+	}
+	return out
+}
+
+// HandleFeeds serves GET /feeds?exchange=&status= with per-feed data quality.
+func HandleFeeds(monitor *feeds.Monitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		exchange := query.Get("exchange")
+		status := query.Get("status")
+		if status != "" && status != feeds.StatusOK && status != feeds.StatusDegraded && status != feeds.StatusStale {
+			writeJSONError(w, http.StatusBadRequest, "status must be ok, degraded or stale")
+			return
+		}
+
+		all := monitor.Feeds(time.Now())
+		resp := FeedsResponse{
+			Summary:   toFeedSummary(feeds.Summarize(all)),
+			Exchanges: []ExchangeView{},
+			Feeds:     []FeedView{},
+		}
+
+		for _, e := range monitor.Exchanges() {
+			if exchange != "" && e.Name != exchange {
+				continue
+			}
+			resp.Exchanges = append(resp.Exchanges, ExchangeView{
+				Name:         e.Name,
+				Connected:    e.Connections > 0,
+				Connections:  e.Connections,
+				Reconnects:   e.Reconnects,
+				DecodeErrors: e.DecodeErrors,
+				LastConnect:  formatOptionalTime(e.LastConnect),
+				LastError:    e.LastError,
+			})
+		}
+
+		for _, f := range all {
+			if exchange != "" && f.Exchange != exchange {
+				continue
+			}
+			if status != "" && f.Status != status {
+				continue
+			}
+			resp.Feeds = append(resp.Feeds, FeedView{
+				Exchange:     f.Exchange,
+				Symbol:       f.Symbol,
+				Status:       f.Status,
+				Reasons:      f.Reasons,
+				LastTick:     formatOptionalTime(f.LastTick),
+				AgeSeconds:   f.Age.Seconds(),
+				TickRate:     f.TickRate,
+				Ticks:        f.Ticks,
+				Rejected:     f.Rejected,
+				DecodeErrors: f.DecodeErrors,
+				Gaps:         f.Gaps,
+				LastGap:      formatOptionalTime(f.LastGap),
+			})
+		}
+
+		writeJSONResponse(w, http.StatusOK, resp)
+	}
+}
@@ -43,41 +43,6 @@ func HandleLatest(redisClient *cache.RedisClient, db *sql.DB) http.HandlerFunc {
 
 		if len(parts) == 1 {
 			symbol = parts[0]
-			price, err := redisClient.GetLatestPrice(ctx, "", symbol)
-			if err != nil {
-				// Use a separate context with longer timeout for PostgreSQL fallback
-				pgCtx, pgCancel := context.WithTimeout(r.Context(), 500*time.Millisecond)
-				defer pgCancel()
-
-				row := db.QueryRowContext(pgCtx,
-					"SELECT exchange, price FROM price_raw WHERE symbol = $1 ORDER BY timestamp DESC LIMIT 1",
-					symbol)
-
-				var lastPrice float64
-				if err := row.Scan(&exchange, &lastPrice); err != nil {
-					if err == sql.ErrNoRows {
-						http.Error(w, "price not available", http.StatusNotFound)
-						return
-					}
-					http.Error(w, "database error", http.StatusInternalServerError)
-					return
-				}
-				price = lastPrice
-			}
-
-			response := map[string]interface{}{
-				"symbol":    symbol,
-				"exchange":  exchange,
-				"price":     price,
-				"timestamp": time.Now().UTC().Format(time.RFC3339),
-				"source":    "redis",
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			if err := json.NewEncoder(w).Encode(response); err != nil {
-				log.Printf("Failed to encode response: %v", err)
-			}
-			return
 		} else if len(parts) == 2 {
 			exchange = parts[0]
 			symbol = parts[1]
@@ -86,18 +51,15 @@ func HandleLatest(redisClient *cache.RedisClient, db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		price, err := redisClient.GetLatestPrice(ctx, exchange, symbol)
+		source := "redis"
+		latest, err := redisClient.GetLatestPrice(ctx, exchange, symbol)
 		if err != nil {
 			// Use a separate context with longer timeout for PostgreSQL fallback
 			pgCtx, pgCancel := context.WithTimeout(r.Context(), 500*time.Millisecond)
 			defer pgCancel()
 
-			row := db.QueryRowContext(pgCtx,
-				"SELECT price FROM price_raw WHERE exchange = $1 AND symbol = $2 ORDER BY timestamp DESC LIMIT 1",
-				exchange, symbol)
-
-			var lastPrice float64
-			if err := row.Scan(&lastPrice); err != nil {
+			latest, err = queryLatestPrice(pgCtx, db, exchange, symbol)
+			if err != nil {
 				if err == sql.ErrNoRows {
 					http.Error(w, "price not available", http.StatusNotFound)
 				} else {
@@ -105,15 +67,15 @@ func HandleLatest(redisClient *cache.RedisClient, db *sql.DB) http.HandlerFunc {
 				}
 				return
 			}
-			price = lastPrice
+			source = "postgres"
 		}
 
 		response := map[string]interface{}{
 			"symbol":    symbol,
-			"exchange":  exchange,
-			"price":     price,
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"source":    "redis",
+			"exchange":  latest.Exchange,
+			"price":     latest.Price,
+			"timestamp": latest.Timestamp.UTC().Format(time.RFC3339Nano),
+			"source":    source,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -123,31 +85,171 @@ func HandleLatest(redisClient *cache.RedisClient, db *sql.DB) http.HandlerFunc {
 	}
 }
 
-func queryAggregatedValue(db *sql.DB, fn string, pair, exchange string, duration string) (float64, string, error) {
-	baseQuery := fmt.Sprintf("SELECT %s(average_price), exchange FROM aggregated_prices WHERE symbol = $1", fn)
+func queryLatestPrice(ctx context.Context, db *sql.DB, exchange, symbol string) (*cache.LatestPrice, error) {
+	query := "SELECT exchange, price, timestamp FROM price_raw WHERE symbol = $1"
+	args := []interface{}{symbol}
+	if exchange != "" {
+		query += " AND exchange = $2"
+		args = append(args, exchange)
+	}
+	query += " ORDER BY timestamp DESC LIMIT 1"
+
+	latest := &cache.LatestPrice{Symbol: symbol}
+	err := db.QueryRowContext(ctx, query, args...).Scan(&latest.Exchange, &latest.Price, &latest.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+// AggregateValue is a single figure of an aggregate response. For highest
+// and lowest Timestamp is when the extreme occurred, for average it is the
+// newest data point used. For all three, Count is the number of ticks
+// (Redis) or aggregated_prices rows (PostgreSQL) in the range; those rows
+// are written every ingestion.aggregate_every ticks or aggregate_interval,
+// not once a minute.
+type AggregateValue struct {
+	Exchange  string  `json:"exchange,omitempty"`
+	Value     float64 `json:"value"`
+	Timestamp string  `json:"timestamp,omitempty"`
+	Count     int     `json:"count"`
+}
+
+// AggregateResponse is shared by /prices/highest, /prices/lowest and
+// /prices/average. Without an exchange in the path the top level value is
+// the cross-exchange figure and Exchanges holds the per-exchange breakdown.
+type AggregateResponse struct {
+	Symbol string `json:"symbol"`
+	Type   string `json:"type"`
+	Period string `json:"period,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Source string `json:"source"`
+	AggregateValue
+	Exchanges []AggregateValue `json:"exchanges,omitempty"`
+}
+
+var aggregateTypeNames = map[string]string{
+	"MAX": "highest",
+	"MIN": "lowest",
+	"AVG": "average",
+}
+
+func queryAggregatedValues(ctx context.Context, db *sql.DB, fn string, pair, exchange string, timeRange domain.TimeRange) ([]AggregateValue, error) {
+	var query string
+	switch fn {
+	case "MAX":
+		query = `SELECT DISTINCT ON (exchange) exchange, max_price, timestamp, COUNT(*) OVER (PARTITION BY exchange) FROM aggregated_prices WHERE symbol = $1`
+	case "MIN":
+		query = `SELECT DISTINCT ON (exchange) exchange, min_price, timestamp, COUNT(*) OVER (PARTITION BY exchange) FROM aggregated_prices WHERE symbol = $1`
+	case "AVG":
+		query = `SELECT exchange, AVG(average_price), MAX(timestamp), COUNT(*) FROM aggregated_prices WHERE symbol = $1`
+	default:
+		return nil, fmt.Errorf("invalid aggregation type %q", fn)
+	}
 	args := []interface{}{pair}
 	argIdx := 2
 
 	if exchange != "" {
-		baseQuery += fmt.Sprintf(" AND exchange = $%d", argIdx)
+		query += fmt.Sprintf(" AND exchange = $%d", argIdx)
 		args = append(args, exchange)
 		argIdx++
 	}
 
-	if duration != "" {
-		baseQuery += fmt.Sprintf(" AND timestamp > now() - $%d::interval", argIdx)
-		args = append(args, duration)
+	if !timeRange.From.IsZero() {
+		query += fmt.Sprintf(" AND timestamp >= $%d", argIdx)
+		args = append(args, timeRange.From)
+		argIdx++
 	}
 
-	baseQuery += " GROUP BY exchange"
+	query += fmt.Sprintf(" AND timestamp < $%d", argIdx)
+	args = append(args, timeRange.To)
+
+	switch fn {
+	case "MAX":
+		query += " ORDER BY exchange, max_price DESC, timestamp DESC"
+	case "MIN":
+		query += " ORDER BY exchange, min_price ASC, timestamp DESC"
+	case "AVG":
+		query += " GROUP BY exchange ORDER BY exchange"
+	}
 
-	var result float64
-	var exch string
-	err := db.QueryRow(baseQuery, args...).Scan(&result, &exch)
-	return result, exch, err
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []AggregateValue
+	for rows.Next() {
+		var v AggregateValue
+		var ts time.Time
+		if err := rows.Scan(&v.Exchange, &v.Value, &ts, &v.Count); err != nil {
+			return nil, err
+		}
+		v.Timestamp = ts.UTC().Format(time.RFC3339)
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return values, nil
 }
 
-func HandleAggregatedValue(db *sql.DB, aggType string) http.HandlerFunc {
+func windowStatsToValues(fn string, stats []cache.WindowStats) []AggregateValue {
+	values := make([]AggregateValue, 0, len(stats))
+	for _, st := range stats {
+		v := AggregateValue{Exchange: st.Exchange, Count: st.Count}
+		switch fn {
+		case "MAX":
+			v.Value, v.Timestamp = st.Max, st.MaxAt.Format(time.RFC3339)
+		case "MIN":
+			v.Value, v.Timestamp = st.Min, st.MinAt.Format(time.RFC3339)
+		case "AVG":
+			v.Value, v.Timestamp = st.Avg, st.LastAt.Format(time.RFC3339)
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// combineAggregate folds per-exchange values into the cross-exchange figure:
+// the extreme keeps the exchange and time it came from, the average is
+// weighted by Count.
+func combineAggregate(fn string, values []AggregateValue) AggregateValue {
+	if len(values) == 1 {
+		return values[0]
+	}
+	if fn != "AVG" {
+		best := values[0]
+		for _, v := range values[1:] {
+			if (fn == "MAX" && v.Value > best.Value) || (fn == "MIN" && v.Value < best.Value) {
+				best = v
+			}
+		}
+		return best
+	}
+
+	var total AggregateValue
+	var sum float64
+	for _, v := range values {
+		sum += v.Value * float64(v.Count)
+		total.Count += v.Count
+		if v.Timestamp > total.Timestamp {
+			total.Timestamp = v.Timestamp
+		}
+	}
+	if total.Count > 0 {
+		total.Value = sum / float64(total.Count)
+	}
+	return total
+}
+
+func HandleAggregatedValue(db *sql.DB, redisClient *cache.RedisClient, aggType string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
 		defer func() {
@@ -209,44 +311,68 @@ func HandleAggregatedValue(db *sql.DB, aggType string) http.HandlerFunc {
 			}
 		}
 
-		duration := r.URL.Query().Get("period")
-		if duration != "" {
-			if _, err := time.ParseDuration(duration); err != nil {
-				http.Error(w, "invalid period format", http.StatusBadRequest)
-				return
-			}
+		query := r.URL.Query()
+		period := query.Get("period")
+		timeRange, err := domain.ParseRange(period, query.Get("from"), query.Get("to"), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		result, exch, err := queryAggregatedValue(db, aggType, symbol, exchange, duration)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				http.Error(w, "no data available", http.StatusNotFound)
+		// Redis only helps for lookbacks ending now
+		var window time.Duration
+		if !timeRange.From.IsZero() && query.Get("to") == "" {
+			window = timeRange.To.Sub(timeRange.From)
+		}
+
+		var (
+			values []AggregateValue
+			source string
+		)
+
+		// Short windows are still held in Redis, so try there first
+		if window > 0 && window <= redisClient.PriceRetention() {
+			stats, err := redisClient.GetWindowStats(r.Context(), exchange, symbol, window)
+			if err == nil {
+				values, source = windowStatsToValues(aggType, stats), "redis"
 			} else {
-				slog.Error("Database query failed", "error", err)
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				slog.Debug("Redis window stats unavailable, falling back to PostgreSQL", "error", err)
 			}
-			return
 		}
 
-		response := map[string]interface{}{
-			"symbol":   symbol,
-			"exchange": exch,
-			"period":   duration,
-		}
+		if values == nil {
+			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+			defer cancel()
 
-		switch aggType {
-		case "AVG":
-			response["average"] = result
-		case "MAX":
-			response["max"] = result
-		case "MIN":
-			response["min"] = result
+			values, err = queryAggregatedValues(ctx, db, aggType, symbol, exchange, timeRange)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					http.Error(w, "no data available", http.StatusNotFound)
+				} else {
+					slog.Error("Database query failed", "error", err)
+					http.Error(w, "internal server error", http.StatusInternalServerError)
+				}
+				return
+			}
+			source = "postgres"
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			slog.Error("Failed to encode response", "error", err)
+		response := AggregateResponse{
+			Symbol:         symbol,
+			Type:           aggregateTypeNames[aggType],
+			Period:         period,
+			Source:         source,
+			AggregateValue: combineAggregate(aggType, values),
+		}
+		if !timeRange.From.IsZero() {
+			response.From = timeRange.From.Format(time.RFC3339)
+			response.To = timeRange.To.Format(time.RFC3339)
 		}
+		if exchange == "" {
+			response.Exchanges = values
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
 	}
 }
 
@@ -278,52 +404,6 @@ func writeJSONResponse(w http.ResponseWriter, status int, data interface{}) {
 	}
 }
 
-func HealthHandler(db *sql.DB, redis *cache.RedisClient) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		status := map[string]string{}
-
-		if err := db.Ping(); err != nil {
-			status["postgres"] = "unhealthy"
-		} else {
-			status["postgres"] = "healthy"
-		}
-
-		// if err := cache.Ping(); err != nil {
-		// 	status["redis"] = "unhealthy"
-		// } else {
-		// 	status["redis"] = "healthy"
-		// }
-
-		status["workers"] = "running" // можно доработать позже
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(status)
-	}
-}
-
-func HandleHealthCheck(db *sql.DB, rc *cache.RedisClient, modeManager *domain.Manager) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		status := map[string]string{
-			"postgres": "ok",
-			"redis":    "ok",
-			"workers":  "running",
-			"mode":     modeManager.GetMode().String(),
-		}
-
-		if err := db.Ping(); err != nil {
-			status["postgres"] = "down"
-		}
-		if err := rc.Ping(); err != nil {
-			status["redis"] = "down"
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(status); err != nil {
-			log.Printf("Failed to encode health check response: %v", err)
-		}
-	}
-}
-
 func writeJSONError(w http.ResponseWriter, status int, message string) {
 	slog.Warn("Returning error", "status", status, "message", message)
 	w.Header().Set("Content-Type", "application/json")
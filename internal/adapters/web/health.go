@@ -0,0 +1,308 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync"
+	"time"
+
+	"marketflow/internal/adapters/cache"
+	"marketflow/internal/adapters/storage"
+	"marketflow/internal/domain"
+	"marketflow/internal/feeds"
+	"marketflow/internal/worker"
+)
+
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+
+	postgresCheckTime = 2 * time.Second
+)
+
+// HealthConfig holds the readiness thresholds.
+type HealthConfig struct {
+	// CacheTTL keeps a burst of probes from all pinging the backends.
+	CacheTTL     time.Duration
+	SlowPostgres time.Duration
+	// QueueHighWater is the fill ratio at which a queue counts as backed up.
+	QueueHighWater  float64
+	FlushStaleAfter time.Duration
+}
+
+// ComponentCheck is the result of one readiness check. Only critical
+// components can make the service unready.
+type ComponentCheck struct {
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	Critical  bool        `json:"critical"`
+	LatencyMs float64     `json:"latency_ms,omitempty"`
+	Message   string      `json:"message,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+type HealthReport struct {
+	Status    string           `json:"status"`
+	Mode      string           `json:"mode"`
+	CheckedAt string           `json:"checked_at"`
+	Checks    []ComponentCheck `json:"checks"`
+}
+
+type LivenessResponse struct {
+	Status        string  `json:"status"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+type QueueView struct {
+	Name string  `json:"name"`
+	Len  int     `json:"len"`
+	Cap  int     `json:"cap"`
+	Fill float64 `json:"fill"`
+}
+
+// HealthChecker runs the readiness checks and caches the report for
+// HealthConfig.CacheTTL.
+type HealthChecker struct {
+	cfg         HealthConfig
+	db          *sql.DB
+	redis       *cache.RedisClient
+	modeManager *domain.Manager
+	monitor     *feeds.Monitor
+	pipeline    *worker.Pipeline
+	started     time.Time
+
+	// mu is held for the whole check so concurrent callers wait for one
+	// run instead of starting their own.
+	mu       sync.Mutex
+	cached   HealthReport
+	cachedAt time.Time
+}
+
+func NewHealthChecker(db *sql.DB, redis *cache.RedisClient, modeManager *domain.Manager, monitor *feeds.Monitor, pipeline *worker.Pipeline, cfg HealthConfig) *HealthChecker {
+	return &HealthChecker{
+		cfg:         cfg,
+		db:          db,
+		redis:       redis,
+		modeManager: modeManager,
+		monitor:     monitor,
+		pipeline:    pipeline,
+		started:     time.Now(),
+	}
+}
+
+// Report returns the cached report or runs the checks if it has expired.
+func (h *HealthChecker) Report(ctx context.Context) HealthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.cachedAt.IsZero() && time.Since(h.cachedAt) < h.cfg.CacheTTL {
+		return h.cached
+	}
+
+	// Other callers share this result, so one of them hanging up must not
+	// fail the checks
+	ctx = context.WithoutCancel(ctx)
+
+	now := time.Now()
+	mode := h.modeManager.GetMode()
+	checks := []ComponentCheck{
+		h.checkPostgres(ctx),
+		h.checkRedis(),
+		h.checkExchanges(mode),
+		h.checkFeeds(now),
+		h.checkQueues(),
+		h.checkFlush(now),
+	}
+
+	report := HealthReport{
+		Status:    healthOK,
+		Mode:      mode.String(),
+		CheckedAt: now.UTC().Format(time.RFC3339Nano),
+		Checks:    checks,
+	}
+	for _, c := range checks {
+		switch {
+		case c.Status == healthDown && c.Critical:
+			report.Status = healthDown
+		case c.Status != healthOK && report.Status == healthOK:
+			report.Status = healthDegraded
+		}
+	}
+
+	h.cached, h.cachedAt = report, now
+	return report
+}
+
+func (h *HealthChecker) checkPostgres(ctx context.Context) ComponentCheck {
+	check := ComponentCheck{Name: "postgres", Status: healthOK, Critical: true}
+
+	ctx, cancel := context.WithTimeout(ctx, postgresCheckTime)
+	defer cancel()
+
+	start := time.Now()
+	err := h.db.PingContext(ctx)
+	latency := time.Since(start)
+	check.LatencyMs = float64(latency.Microseconds()) / 1000
+
+	switch {
+	case err != nil:
+		check.Status = healthDown
+		check.Message = err.Error()
+	case latency > h.cfg.SlowPostgres:
+		check.Status = healthDegraded
+		check.Message = "slow ping"
+	}
+	return check
+}
+
+// Redis is only a cache in front of PostgreSQL, so it never makes the
+// service unready.
+func (h *HealthChecker) checkRedis() ComponentCheck {
+	state := h.redis.CircuitState()
+	check := ComponentCheck{
+		Name:    "redis",
+		Status:  healthOK,
+		Details: map[string]string{"circuit": state, "addr": h.redis.Addr()},
+	}
+
+	switch state {
+	case "open":
+		check.Status = healthDown
+		check.Message = "circuit open"
+		return check
+	case "half-open":
+		check.Status = healthDegraded
+		check.Message = "circuit half-open"
+		return check
+	}
+
+	start := time.Now()
+	err := h.redis.Ping()
+	check.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		check.Status = healthDown
+		check.Message = err.Error()
+	}
+	return check
+}
+
+func (h *HealthChecker) checkExchanges(mode domain.Mode) ComponentCheck {
+	check := ComponentCheck{Name: "exchanges", Status: healthOK}
+
+	exchanges := h.monitor.Exchanges()
+	views := make([]ExchangeView, 0, len(exchanges))
+	connected := 0
+	for _, e := range exchanges {
+		if e.Connections > 0 {
+			connected++
+		}
+		views = append(views, ExchangeView{
+			Name:         e.Name,
+			Connected:    e.Connections > 0,
+			Connections:  e.Connections,
+			Reconnects:   e.Reconnects,
+			DecodeErrors: e.DecodeErrors,
+			LastConnect:  formatOptionalTime(e.LastConnect),
+			LastError:    e.LastError,
+		})
+	}
+	check.Details = views
+
+	if mode != domain.ModeLive {
+		check.Message = "test mode, exchanges not used"
+		return check
+	}
+	switch {
+	case len(exchanges) == 0:
+		check.Status = healthDegraded
+		check.Message = "no connection attempted yet"
+	case connected == 0:
+		check.Status = healthDown
+		check.Message = "no exchange connected"
+	case connected < len(exchanges):
+		check.Status = healthDegraded
+		check.Message = "some exchanges disconnected"
+	}
+	return check
+}
+
+func (h *HealthChecker) checkFeeds(now time.Time) ComponentCheck {
+	summary := toFeedSummary(feeds.Summarize(h.monitor.Feeds(now)))
+	check := ComponentCheck{Name: "feeds", Status: healthOK, Details: summary}
+	if len(summary.Stale) > 0 || len(summary.Degraded) > 0 {
+		check.Status = healthDegraded
+		check.Message = "stale or degraded feeds"
+	}
+	return check
+}
+
+func (h *HealthChecker) checkQueues() ComponentCheck {
+	check := ComponentCheck{Name: "queues", Status: healthOK}
+
+	queues := h.pipeline.Queues()
+	views := make([]QueueView, 0, len(queues))
+	for _, q := range queues {
+		var fill float64
+		if q.Cap > 0 {
+			fill = float64(q.Len) / float64(q.Cap)
+		}
+		if fill >= h.cfg.QueueHighWater {
+			check.Status = healthDegraded
+			check.Message = "queue above high water mark"
+		}
+		views = append(views, QueueView{Name: q.Name, Len: q.Len, Cap: q.Cap, Fill: fill})
+	}
+	check.Details = views
+	return check
+}
+
+func (h *HealthChecker) checkFlush(now time.Time) ComponentCheck {
+	check := ComponentCheck{Name: "batch_flush", Status: healthOK}
+
+	last := storage.LastFlush()
+	if last.IsZero() {
+		// Give the first batch time to fill before complaining
+		if now.Sub(h.started) > h.cfg.FlushStaleAfter {
+			check.Status = healthDegraded
+			check.Message = "no batch flushed yet"
+		}
+		return check
+	}
+
+	age := now.Sub(last)
+	check.Details = map[string]interface{}{
+		"last_flush":  last.UTC().Format(time.RFC3339Nano),
+		"age_seconds": age.Seconds(),
+	}
+	if age > h.cfg.FlushStaleAfter {
+		check.Status = healthDegraded
+		check.Message = "no recent batch flush"
+	}
+	return check
+}
+
+// HandleLiveness serves GET /health/live. It checks nothing external, so a
+// dependency outage never gets the process restarted.
+func HandleLiveness(started time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, http.StatusOK, LivenessResponse{
+			Status:        healthOK,
+			UptimeSeconds: time.Since(started).Seconds(),
+		})
+	}
+}
+
+// HandleReadiness serves GET /health/ready and GET /health, answering 503
+// when a critical component is down.
+func HandleReadiness(checker *HealthChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Report(r.Context())
+		status := http.StatusOK
+		if report.Status == healthDown {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSONResponse(w, status, report)
+	}
+}
@@ -0,0 +1,277 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/adapters/storage"
+	"marketflow/internal/domain"
+	"marketflow/internal/indicators"
+	"marketflow/internal/stream"
+)
+
+const defaultIndicatorLength = 20
+
+// IndicatorResponse is column oriented like CandlesResponse. Value entries
+// are null while the indicator warms up.
+type IndicatorResponse struct {
+	Symbol   string     `json:"symbol"`
+	Exchange string     `json:"exchange,omitempty"`
+	Type     string     `json:"type"`
+	Length   int        `json:"length"`
+	Interval string     `json:"interval"`
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	Time     []int64    `json:"time"`
+	Close    []float64  `json:"close"`
+	Value    []*float64 `json:"value"`
+	Upper    []*float64 `json:"upper,omitempty"`
+	Lower    []*float64 `json:"lower,omitempty"`
+}
+
+type IndicatorEvent struct {
+	Exchange string   `json:"exchange"`
+	Symbol   string   `json:"symbol"`
+	Type     string   `json:"type"`
+	Length   int      `json:"length"`
+	Time     int64    `json:"time"`
+	Close    float64  `json:"close"`
+	Value    float64  `json:"value"`
+	Upper    *float64 `json:"upper,omitempty"`
+	Lower    *float64 `json:"lower,omitempty"`
+}
+
+// parseIndicator reads type and length from the query.
+func parseIndicator(r *http.Request) (string, int, indicators.Indicator, error) {
+	query := r.URL.Query()
+	kind := query.Get("type")
+	if kind == "" {
+		kind = indicators.TypeSMA
+	}
+	length := defaultIndicatorLength
+	if l := query.Get("length"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil {
+			return "", 0, nil, err
+		}
+		length = n
+	}
+	ind, err := indicators.New(kind, length)
+	return kind, length, ind, err
+}
+
+// HandleIndicator serves GET /indicators/{symbol}?exchange=&type=&length=&interval=&period=&from=&to=&limit=
+func HandleIndicator(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		symbol := r.PathValue("symbol")
+		exchange := query.Get("exchange")
+
+		kind, length, ind, err := parseIndicator(r)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		intervalName := query.Get("interval")
+		if intervalName == "" {
+			intervalName = "1m"
+		}
+		interval, ok := candleIntervals[intervalName]
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "interval must be one of 1m, 5m, 15m, 1h")
+			return
+		}
+
+		limit := defaultCandlePoints
+		if l := query.Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n <= 0 || n > maxCandlePoints {
+				writeJSONError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxCandlePoints))
+				return
+			}
+			limit = n
+		}
+
+		timeRange, err := domain.ParseRange(query.Get("period"), query.Get("from"), query.Get("to"), time.Now())
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		to, from := timeRange.To, timeRange.From
+		if from.IsZero() {
+			from = to.Add(-time.Duration(limit) * interval)
+		}
+		from = from.Truncate(interval)
+		if points := int(to.Sub(from) / interval); points > limit {
+			writeJSONError(w, http.StatusBadRequest, "range has "+strconv.Itoa(points)+" points, more than limit "+strconv.Itoa(limit)+"; use a larger interval")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+
+		// Load enough bars before from that the first returned value is settled
+		warmFrom := from.Add(-time.Duration(ind.Warmup()) * interval)
+		candles, err := storage.QueryCandles(ctx, db, symbol, exchange, interval, warmFrom, to)
+		if err != nil {
+			slog.Error("Indicator query failed", "error", err)
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		// Indicator lengths count bars, so empty buckets carry the last close
+		candles = fillCandleGaps(candles, warmFrom, to, interval)
+
+		closes := make([]float64, len(candles))
+		for i, c := range candles {
+			closes[i] = c.Close
+		}
+		values := indicators.Compute(ind, closes)
+
+		resp := IndicatorResponse{
+			Symbol:   symbol,
+			Exchange: exchange,
+			Type:     kind,
+			Length:   length,
+			Interval: intervalName,
+			From:     from.Format(time.RFC3339),
+			To:       to.Format(time.RFC3339),
+			Time:     []int64{},
+			Close:    []float64{},
+			Value:    []*float64{},
+		}
+		bands := kind == indicators.TypeBollinger
+		for i, c := range candles {
+			if c.Start.Before(from) {
+				continue
+			}
+			resp.Time = append(resp.Time, c.Start.Unix())
+			resp.Close = append(resp.Close, c.Close)
+			v := values[i]
+			if v == nil {
+				resp.Value = append(resp.Value, nil)
+				if bands {
+					resp.Upper = append(resp.Upper, nil)
+					resp.Lower = append(resp.Lower, nil)
+				}
+				continue
+			}
+			resp.Value = append(resp.Value, &v.Value)
+			if bands {
+				resp.Upper = append(resp.Upper, &v.Upper)
+				resp.Lower = append(resp.Lower, &v.Lower)
+			}
+		}
+
+		writeJSONResponse(w, http.StatusOK, resp)
+	}
+}
+
+// HandleStreamIndicator serves GET /stream/indicators/{symbol}?exchange=&type=&length=
+// as SSE. The indicator is seeded from stored one-minute bars and then
+// updated as each live minute candle closes.
+func HandleStreamIndicator(db *sql.DB, hub *stream.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbol := r.PathValue("symbol")
+		exchange := r.URL.Query().Get("exchange")
+		if exchange == "" {
+			http.Error(w, "exchange is required", http.StatusBadRequest)
+			return
+		}
+
+		kind, length, ind, err := parseIndicator(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		// Subscribe before seeding so no candle closes unseen in between
+		filter := eventFilter(map[string]bool{symbol: true}, map[string]bool{exchange: true}, "candle")
+		sub := hub.Subscribe(0, sseClientBuffer, filter)
+		defer hub.Unsubscribe(sub)
+
+		to := time.Now().UTC().Truncate(time.Minute)
+		from := to.Add(-time.Duration(ind.Warmup()) * time.Minute)
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		history, err := storage.QueryCandles(ctx, db, symbol, exchange, time.Minute, from, to)
+		cancel()
+		if err != nil {
+			slog.Error("Indicator seed query failed", "error", err)
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+
+		var last time.Time
+		var lastClose float64
+		for _, c := range fillCandleGaps(history, from, to, time.Minute) {
+			ind.Update(c.Close)
+			last, lastClose = c.Start, c.Close
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		rc.Flush()
+
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				c, _ := ev.Payload.(domain.Candle)
+				if !c.Start.After(last) {
+					continue
+				}
+				// Minutes without ticks produce no candle; carry the last
+				// close through them as the seeded history does
+				if !last.IsZero() {
+					for gap := last.Add(time.Minute); gap.Before(c.Start); gap = gap.Add(time.Minute) {
+						ind.Update(lastClose)
+					}
+				}
+				last, lastClose = c.Start, c.Close
+				v, ready := ind.Update(c.Close)
+				if !ready {
+					continue
+				}
+				event := IndicatorEvent{
+					Exchange: c.Exchange,
+					Symbol:   c.Symbol,
+					Type:     kind,
+					Length:   length,
+					Time:     c.Start.Unix(),
+					Close:    c.Close,
+					Value:    v.Value,
+				}
+				if kind == indicators.TypeBollinger {
+					event.Upper, event.Lower = &v.Upper, &v.Lower
+				}
+				if err := writeSSE(w, ev.ID, "indicator", event); err != nil {
+					return
+				}
+				rc.Flush()
+			case <-keepAlive.C:
+				if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+					return
+				}
+				rc.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/pkg/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec("marketflow_http_requests_total", "HTTP requests by route and status.", "method", "route", "status")
+	httpSeconds  = metrics.NewHistogramVec("marketflow_http_request_duration_seconds", "HTTP request latency by route; streams count until they close.", nil, "method", "route")
+)
+
+// statusRecorder captures the status code while keeping Flush and Hijack
+// reachable for the streaming and WebSocket handlers.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection cannot be hijacked")
+	}
+	// A successful upgrade never calls WriteHeader
+	s.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// InstrumentHandler records request counts and latency labelled with the
+// matched mux pattern, so path values do not explode the label set.
+func InstrumentHandler(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		mux.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		httpRequests.With(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		httpSeconds.With(r.Method, route).ObserveSince(start)
+	})
+}
@@ -3,14 +3,22 @@ package web
 import (
 	"database/sql"
 	"net/http"
+	"time"
 
 	"marketflow/internal/adapters/cache"
+	"marketflow/internal/alerts"
+	"marketflow/internal/arbitrage"
+	"marketflow/internal/config"
 	"marketflow/internal/domain"
+	"marketflow/internal/feeds"
+	"marketflow/internal/stream"
+	"marketflow/internal/worker"
+	"marketflow/pkg/metrics"
 
 	_ "net/http"
 )
 
-func NewRouter(db *sql.DB, redisClient *cache.RedisClient, modeManager *domain.Manager) *http.ServeMux {
+func NewRouter(db *sql.DB, redisClient *cache.RedisClient, modeManager *domain.Manager, hub *stream.Hub, detector *arbitrage.Detector, alertEngine *alerts.Engine, statsEngine *worker.StatsEngine, monitor *feeds.Monitor, pipeline *worker.Pipeline, reloader *config.Reloader, health HealthConfig) *http.ServeMux {
 	mux := http.NewServeMux()
 	handler := &Handler{
 		DB:          db,
@@ -23,11 +31,42 @@ func NewRouter(db *sql.DB, redisClient *cache.RedisClient, modeManager *domain.M
 
 	mux.HandleFunc("GET /prices/latest/{symbol}", HandleLatest(redisClient, db))
 	mux.HandleFunc("GET /prices/latest/{exchange}/{symbol}", HandleLatest(redisClient, db))
+	mux.HandleFunc("GET /prices/consolidated/{symbol}", HandleConsolidated(redisClient, db))
 
-	mux.HandleFunc("/prices/highest/", HandleAggregatedValue(db, "MAX"))
-	mux.HandleFunc("/prices/lowest/", HandleAggregatedValue(db, "MIN"))
-	mux.HandleFunc("/prices/average/", HandleAggregatedValue(db, "AVG"))
-	mux.HandleFunc("GET /health", HandleHealthCheck(db, redisClient, modeManager))
+	mux.HandleFunc("/prices/highest/", HandleAggregatedValue(db, redisClient, "MAX"))
+	mux.HandleFunc("/prices/lowest/", HandleAggregatedValue(db, redisClient, "MIN"))
+	mux.HandleFunc("/prices/average/", HandleAggregatedValue(db, redisClient, "AVG"))
+	mux.HandleFunc("GET /prices/twap/{symbol}", HandleWeightedAverage(db, "twap"))
+	mux.HandleFunc("GET /prices/twap/{exchange}/{symbol}", HandleWeightedAverage(db, "twap"))
+	mux.HandleFunc("GET /prices/vwap/{symbol}", HandleWeightedAverage(db, "vwap"))
+	mux.HandleFunc("GET /prices/vwap/{exchange}/{symbol}", HandleWeightedAverage(db, "vwap"))
+
+	mux.HandleFunc("GET /candles/{symbol}", HandleCandles(db))
+	mux.HandleFunc("GET /ticks/{exchange}/{symbol}", HandleTicks(db))
+
+	mux.HandleFunc("GET /stats/{exchange}/{symbol}", HandleStats(statsEngine))
+	mux.HandleFunc("GET /analytics/correlation", HandleCorrelation(db))
+	mux.HandleFunc("GET /indicators/{symbol}", HandleIndicator(db))
+	mux.HandleFunc("GET /arbitrage", HandleArbitrage(detector, db))
+
+	mux.HandleFunc("POST /alerts", HandleCreateAlert(alertEngine))
+	mux.HandleFunc("GET /alerts", HandleListAlerts(alertEngine))
+	mux.HandleFunc("DELETE /alerts/{id}", HandleDeleteAlert(alertEngine))
+
+	mux.HandleFunc("GET /stream/prices", HandleStreamPrices(hub))
+	mux.HandleFunc("GET /stream/arbitrage", HandleStreamArbitrage(hub))
+	mux.HandleFunc("GET /stream/indicators/{symbol}", HandleStreamIndicator(db, hub))
+	mux.HandleFunc("GET /ws", HandleWebSocket(hub))
+
+	mux.HandleFunc("GET /feeds", HandleFeeds(monitor))
+	checker := NewHealthChecker(db, redisClient, modeManager, monitor, pipeline, health)
+	mux.HandleFunc("GET /health", HandleReadiness(checker))
+	mux.HandleFunc("GET /health/ready", HandleReadiness(checker))
+	mux.HandleFunc("GET /health/live", HandleLiveness(time.Now()))
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	mux.HandleFunc("GET /admin/config", HandleAdminConfig(reloader))
+	mux.HandleFunc("POST /admin/config/reload", HandleAdminReload(reloader))
 
 	return mux
 }
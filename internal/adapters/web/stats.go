@@ -0,0 +1,79 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/worker"
+)
+
+type StatsResponse struct {
+	Exchange      string  `json:"exchange"`
+	Symbol        string  `json:"symbol"`
+	Window        string  `json:"window"`
+	Ticks         int     `json:"ticks"`
+	TickRate      float64 `json:"tick_rate"`
+	First         float64 `json:"first"`
+	Last          float64 `json:"last"`
+	Return        float64 `json:"return"`
+	LogReturn     float64 `json:"log_return"`
+	MeanLogReturn float64 `json:"mean_log_return"`
+	StdDev        float64 `json:"std_dev"`
+	RealizedVol   float64 `json:"realized_volatility"`
+	AnnualizedVol float64 `json:"annualized_volatility"`
+	UpdatedAt     string  `json:"updated_at"`
+}
+
+// HandleStats serves GET /stats/{exchange}/{symbol}?window=5m from the live
+// statistics kept by the worker layer.
+func HandleStats(engine *worker.StatsEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		exchange := r.PathValue("exchange")
+		symbol := r.PathValue("symbol")
+
+		windowParam := r.URL.Query().Get("window")
+		if windowParam == "" {
+			windowParam = "5m"
+		}
+		period, err := domain.ParsePeriod(windowParam)
+		valid := err == nil
+		window := period.Duration()
+		names := make([]string, 0, len(engine.Windows()))
+		found := false
+		for _, d := range engine.Windows() {
+			names = append(names, d.String())
+			if d == window {
+				found = true
+			}
+		}
+		if !valid || !found {
+			writeJSONError(w, http.StatusBadRequest, "window must be one of "+strings.Join(names, ", "))
+			return
+		}
+
+		st, ok := engine.Snapshot(exchange, symbol, window, time.Now())
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "no data in window")
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, StatsResponse{
+			Exchange:      st.Exchange,
+			Symbol:        st.Symbol,
+			Window:        windowParam,
+			Ticks:         st.Ticks,
+			TickRate:      st.TickRate,
+			First:         st.First,
+			Last:          st.Last,
+			Return:        st.Return,
+			LogReturn:     st.LogReturn,
+			MeanLogReturn: st.MeanLog,
+			StdDev:        st.StdDevLog,
+			RealizedVol:   st.RealizedVol,
+			AnnualizedVol: st.AnnualizedVol,
+			UpdatedAt:     st.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/stream"
+)
+
+const sseClientBuffer = 256
+
+type TickEvent struct {
+	Exchange  string  `json:"exchange"`
+	Symbol    string  `json:"symbol"`
+	Price     float64 `json:"price"`
+	Timestamp string  `json:"timestamp"`
+}
+
+// parseList splits a comma separated query value into a lookup set.
+func parseList(value string) map[string]bool {
+	if value == "" {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+func eventFilter(symbols, exchanges map[string]bool, types ...string) func(stream.Event) bool {
+	return func(ev stream.Event) bool {
+		if len(types) > 0 {
+			matched := false
+			for _, t := range types {
+				if ev.Type == t {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		}
+		if symbols != nil && !symbols[ev.Symbol] {
+			return false
+		}
+		if exchanges != nil && !exchanges[ev.Exchange] {
+			return false
+		}
+		return true
+	}
+}
+
+func toTickEvent(ev stream.Event) TickEvent {
+	te := TickEvent{
+		Exchange:  ev.Exchange,
+		Symbol:    ev.Symbol,
+		Timestamp: ev.Time.Format(time.RFC3339Nano),
+	}
+	if update, ok := ev.Payload.(domain.PriceUpdate); ok {
+		te.Price = update.Price
+		te.Timestamp = update.ReceivedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return te
+}
+
+// parseLastEventID reads the resume point from the Last-Event-ID header or
+// the lastEventId query parameter (for clients that cannot set headers).
+func parseLastEventID(r *http.Request) (uint64, error) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	if lastEventID == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(lastEventID, 10, 64)
+}
+
+// HandleStreamPrices pushes ticks as Server-Sent Events. With ?throttle=1s
+// only the latest tick per exchange/symbol is sent once per interval.
+func HandleStreamPrices(hub *stream.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		var throttle time.Duration
+		if t := query.Get("throttle"); t != "" {
+			d, err := time.ParseDuration(t)
+			if err != nil || d < 100*time.Millisecond {
+				http.Error(w, "invalid throttle", http.StatusBadRequest)
+				return
+			}
+			throttle = d
+		}
+
+		lastID, err := parseLastEventID(r)
+		if err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+
+		// Streams outlive the server's WriteTimeout
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		filter := eventFilter(parseList(query.Get("symbols")), parseList(query.Get("exchanges")), "tick")
+		sub := hub.Subscribe(lastID, sseClientBuffer, filter)
+		defer hub.Unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		rc.Flush()
+
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		var flush <-chan time.Time
+		pending := make(map[string]stream.Event)
+		if throttle > 0 {
+			ticker := time.NewTicker(throttle)
+			defer ticker.Stop()
+			flush = ticker.C
+		}
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+
+			case ev, ok := <-sub.C:
+				if !ok {
+					if sub.Evicted() {
+						writeSSE(w, 0, "error", map[string]string{"error": "client too slow, reconnect with Last-Event-ID"})
+						rc.Flush()
+						slog.Warn("Evicted slow SSE client", "remote", r.RemoteAddr)
+					}
+					return
+				}
+				if throttle > 0 {
+					pending[ev.Exchange+":"+ev.Symbol] = ev
+					continue
+				}
+				if err := writeSSE(w, ev.ID, ev.Type, toTickEvent(ev)); err != nil {
+					return
+				}
+				rc.Flush()
+
+			case <-flush:
+				// In id order, so Last-Event-ID never skips back over sent events
+				batch := make([]stream.Event, 0, len(pending))
+				for _, ev := range pending {
+					batch = append(batch, ev)
+				}
+				clear(pending)
+				sort.Slice(batch, func(i, j int) bool { return batch[i].ID < batch[j].ID })
+				for _, ev := range batch {
+					if err := writeSSE(w, ev.ID, ev.Type, toTickEvent(ev)); err != nil {
+						return
+					}
+				}
+				rc.Flush()
+
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				rc.Flush()
+			}
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, id uint64, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
@@ -0,0 +1,173 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/adapters/storage"
+	"marketflow/internal/domain"
+)
+
+const (
+	defaultTicksLimit = 500
+	maxTicksLimit     = 5000
+	maxStreamLimit    = 1000000
+)
+
+type TickRow struct {
+	ID        int64   `json:"id"`
+	Exchange  string  `json:"exchange"`
+	Symbol    string  `json:"symbol"`
+	Price     float64 `json:"price"`
+	Timestamp string  `json:"timestamp"`
+}
+
+type TicksResponse struct {
+	Ticks      []TickRow `json:"ticks"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+func toTickRow(t storage.RawTick) TickRow {
+	return TickRow{
+		ID:        t.ID,
+		Exchange:  t.Exchange,
+		Symbol:    t.Symbol,
+		Price:     t.Price,
+		Timestamp: t.Timestamp.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func encodeCursor(c storage.TickCursor) string {
+	raw := fmt.Sprintf("%d:%d", c.Timestamp.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*storage.TickCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &storage.TickCursor{Timestamp: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
+
+// HandleTicks serves GET /ticks/{exchange}/{symbol}?from=&to=&period=&limit=&cursor=.
+// JSON pages carry next_cursor; with Accept: application/x-ndjson (or
+// ?format=ndjson) the whole range is streamed one tick per line.
+func HandleTicks(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		exchange := r.PathValue("exchange")
+		symbol := r.PathValue("symbol")
+
+		ndjson := query.Get("format") == "ndjson" ||
+			strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+
+		timeRange, err := domain.ParseRange(query.Get("period"), query.Get("from"), query.Get("to"), time.Now())
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		to, from := timeRange.To, timeRange.From
+		if from.IsZero() {
+			from = to.Add(-time.Hour)
+		}
+
+		limit, maxLimit := defaultTicksLimit, maxTicksLimit
+		if ndjson {
+			limit, maxLimit = 0, maxStreamLimit
+		}
+		if l := query.Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n <= 0 || n > maxLimit {
+				writeJSONError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxLimit))
+				return
+			}
+			limit = n
+		}
+
+		var cursor *storage.TickCursor
+		if c := query.Get("cursor"); c != "" {
+			parsed, err := decodeCursor(c)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, "invalid cursor")
+				return
+			}
+			cursor = parsed
+		}
+
+		if ndjson {
+			streamTicks(w, r, db, exchange, symbol, from, to, cursor, limit)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+
+		resp := TicksResponse{Ticks: []TickRow{}}
+		var last storage.RawTick
+		err = storage.ScanTicks(ctx, db, exchange, symbol, from, to, cursor, limit, func(t storage.RawTick) error {
+			resp.Ticks = append(resp.Ticks, toTickRow(t))
+			last = t
+			return nil
+		})
+		if err != nil {
+			slog.Error("Tick query failed", "error", err)
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+
+		// A full page means there may be more
+		if len(resp.Ticks) == limit {
+			resp.NextCursor = encodeCursor(storage.TickCursor{Timestamp: last.Timestamp, ID: last.ID})
+		}
+
+		writeJSONResponse(w, http.StatusOK, resp)
+	}
+}
+
+func streamTicks(w http.ResponseWriter, r *http.Request, db *sql.DB, exchange, symbol string, from, to time.Time, cursor *storage.TickCursor, limit int) {
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	count := 0
+	err := storage.ScanTicks(r.Context(), db, exchange, symbol, from, to, cursor, limit, func(t storage.RawTick) error {
+		if err := enc.Encode(toTickRow(t)); err != nil {
+			return err
+		}
+		count++
+		if count%1000 == 0 {
+			return rc.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent, the client sees a truncated stream
+		slog.Error("Tick stream aborted", "error", err, "sent", count)
+		return
+	}
+	rc.Flush()
+}
@@ -0,0 +1,438 @@
+package web
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/stream"
+)
+
+const (
+	wsGUID             = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxMessageSize   = 64 * 1024
+	wsMaxSubscriptions = 50
+	wsPingInterval     = 30 * time.Second
+	wsReadTimeout      = 2 * wsPingInterval
+	wsWriteTimeout     = 5 * time.Second
+	wsClientBuffer     = 512
+
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+var errMessageTooLarge = errors.New("message too large")
+
+// wsConn is a minimal server side WebSocket connection (RFC 6455).
+type wsConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+}
+
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		!strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+		return nil, fmt.Errorf("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, fmt.Errorf("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("missing Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("connection cannot be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	sum := sha1.Sum([]byte(key + wsGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, reader: rw.Reader}, nil
+}
+
+// readMessage returns the next data message, answering pings and
+// reassembling fragmented frames along the way.
+func (c *wsConn) readMessage() (int, []byte, error) {
+	var (
+		message []byte
+		msgOp   int
+	)
+
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.writeFrame(opClose, payload)
+			return opClose, payload, io.EOF
+		case opText, opBinary:
+			msgOp = op
+			message = payload
+		case opContinuation:
+			message = append(message, payload...)
+		default:
+			return 0, nil, fmt.Errorf("unknown opcode %d", op)
+		}
+
+		if len(message) > wsMaxMessageSize {
+			return 0, nil, errMessageTooLarge
+		}
+		if fin {
+			return msgOp, message, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (bool, int, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	op := int(header[0] & 0x0F)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if length > wsMaxMessageSize {
+		return false, 0, nil, errMessageTooLarge
+	}
+	// Clients must mask every frame
+	if !masked {
+		return false, 0, nil, fmt.Errorf("unmasked client frame")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, op, payload, nil
+}
+
+func (c *wsConn) writeFrame(op int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := []byte{0x80 | byte(op)}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		header = append(header, 127)
+		header = append(header, ext[:]...)
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+func (c *wsConn) writeJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(opText, data)
+}
+
+func (c *wsConn) close(code int, reason string) {
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], reason)
+	c.writeFrame(opClose, payload)
+	c.conn.Close()
+}
+
+type wsRequest struct {
+	Action   string `json:"action"`
+	Channel  string `json:"channel"`
+	Exchange string `json:"exchange"`
+	Symbol   string `json:"symbol"`
+}
+
+type wsMessage struct {
+	Type     string      `json:"type"`
+	ID       uint64      `json:"id,omitempty"`
+	Channel  string      `json:"channel,omitempty"`
+	Exchange string      `json:"exchange,omitempty"`
+	Symbol   string      `json:"symbol,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+type CandleEvent struct {
+	Exchange string  `json:"exchange"`
+	Symbol   string  `json:"symbol"`
+	Start    string  `json:"start"`
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Close    float64 `json:"close"`
+	Avg      float64 `json:"avg"`
+	Count    int     `json:"count"`
+}
+
+func toCandleEvent(c domain.Candle) CandleEvent {
+	return CandleEvent{
+		Exchange: c.Exchange,
+		Symbol:   c.Symbol,
+		Start:    c.Start.Format(time.RFC3339),
+		Open:     c.Open,
+		High:     c.High,
+		Low:      c.Low,
+		Close:    c.Close,
+		Avg:      c.Avg,
+		Count:    c.Count,
+	}
+}
+
+// channels maps hub event types to subscription channel names.
+var channels = map[string]string{
+	"tick":      "ticks",
+	"candle":    "candles",
+	"mode":      "mode",
+	"arbitrage": "arbitrage",
+}
+
+// wsSubscriptions is the set of channels a connection is subscribed to.
+// An empty exchange or symbol matches any.
+type wsSubscriptions struct {
+	mu   sync.RWMutex
+	keys map[string]bool
+}
+
+func subscriptionKey(channel, exchange, symbol string) string {
+	return channel + ":" + exchange + ":" + symbol
+}
+
+func (s *wsSubscriptions) matches(ev stream.Event) bool {
+	channel, ok := channels[ev.Type]
+	if !ok {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, exch := range []string{ev.Exchange, ""} {
+		for _, sym := range []string{ev.Symbol, ""} {
+			if s.keys[subscriptionKey(channel, exch, sym)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HandleWebSocket serves /ws: clients subscribe to ticks, candles and mode
+// channels per exchange/symbol and receive matching events as JSON messages.
+func HandleWebSocket(hub *stream.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgradeWebSocket(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer ws.conn.Close()
+
+		subs := &wsSubscriptions{keys: make(map[string]bool)}
+		sub := hub.Subscribe(0, wsClientBuffer, subs.matches)
+		defer hub.Unsubscribe(sub)
+
+		done := make(chan struct{})
+		defer close(done)
+		go ws.writeLoop(sub, done)
+
+		for {
+			op, data, err := ws.readMessage()
+			if err != nil {
+				if errors.Is(err, errMessageTooLarge) {
+					ws.close(1009, "message too large")
+				} else if !errors.Is(err, io.EOF) {
+					slog.Debug("WebSocket read failed", "remote", r.RemoteAddr, "error", err)
+				}
+				return
+			}
+			if op != opText {
+				ws.writeJSON(wsMessage{Type: "error", Error: "only text messages are supported"})
+				continue
+			}
+
+			var req wsRequest
+			if err := json.Unmarshal(data, &req); err != nil {
+				ws.writeJSON(wsMessage{Type: "error", Error: "invalid JSON"})
+				continue
+			}
+
+			if err := subs.apply(req); err != nil {
+				ws.writeJSON(wsMessage{Type: "error", Error: err.Error()})
+				continue
+			}
+
+			ws.writeJSON(wsMessage{
+				Type:     req.Action + "d",
+				Channel:  req.Channel,
+				Exchange: req.Exchange,
+				Symbol:   req.Symbol,
+			})
+		}
+	}
+}
+
+func (s *wsSubscriptions) apply(req wsRequest) error {
+	valid := false
+	for _, name := range channels {
+		if req.Channel == name {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("unknown channel %q", req.Channel)
+	}
+
+	key := subscriptionKey(req.Channel, req.Exchange, req.Symbol)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Action {
+	case "subscribe":
+		if !s.keys[key] && len(s.keys) >= wsMaxSubscriptions {
+			return fmt.Errorf("subscription limit of %d reached", wsMaxSubscriptions)
+		}
+		s.keys[key] = true
+	case "unsubscribe":
+		delete(s.keys, key)
+	default:
+		return fmt.Errorf("unknown action %q", req.Action)
+	}
+	return nil
+}
+
+// writeLoop forwards hub events and sends heartbeat pings until the reader
+// side finishes or the client is evicted for falling behind.
+func (c *wsConn) writeLoop(sub *stream.Subscriber, done <-chan struct{}) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case ev, ok := <-sub.C:
+			if !ok {
+				if sub.Evicted() {
+					c.close(1008, "client too slow")
+				}
+				return
+			}
+
+			msg := wsMessage{
+				Type:     ev.Type,
+				ID:       ev.ID,
+				Channel:  channels[ev.Type],
+				Exchange: ev.Exchange,
+				Symbol:   ev.Symbol,
+				Data:     ev.Payload,
+			}
+			switch payload := ev.Payload.(type) {
+			case domain.PriceUpdate:
+				msg.Data = toTickEvent(ev)
+			case domain.Candle:
+				msg.Data = toCandleEvent(payload)
+			case domain.ArbitrageOpportunity:
+				msg.Data = toOpportunityView(payload)
+			}
+
+			if err := c.writeJSON(msg); err != nil {
+				c.conn.Close()
+				return
+			}
+
+		case <-ping.C:
+			if err := c.writeFrame(opPing, nil); err != nil {
+				c.conn.Close()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"marketflow/internal/adapters/storage"
+	"marketflow/internal/domain"
+)
+
+const defaultWeightedPeriod = "5m"
+
+// HandleWeightedAverage serves /prices/twap and /prices/vwap with the same
+// path and query parameters as /prices/average.
+func HandleWeightedAverage(db *sql.DB, kind string) http.HandlerFunc {
+	query := storage.QueryTWAP
+	if kind == "vwap" {
+		query = storage.QueryVWAP
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbol := r.PathValue("symbol")
+		exchange := r.PathValue("exchange")
+
+		params := r.URL.Query()
+		period := params.Get("period")
+		if period == "" && params.Get("from") == "" {
+			period = defaultWeightedPeriod
+		}
+		timeRange, err := domain.ParseRange(period, params.Get("from"), params.Get("to"), time.Now())
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+
+		prices, err := query(ctx, db, symbol, exchange, timeRange.From, timeRange.To)
+		if err != nil {
+			slog.Error("Weighted average query failed", "kind", kind, "error", err)
+			writeJSONError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		if len(prices) == 0 {
+			msg := "no data available"
+			if kind == "vwap" {
+				msg = "no ticks with quantity in range"
+			}
+			writeJSONError(w, http.StatusNotFound, msg)
+			return
+		}
+
+		values := make([]AggregateValue, 0, len(prices))
+		var sum, weight float64
+		var total AggregateValue
+		for _, p := range prices {
+			values = append(values, AggregateValue{
+				Exchange:  p.Exchange,
+				Value:     p.Value,
+				Timestamp: p.LastAt.UTC().Format(time.RFC3339),
+				Count:     p.Count,
+			})
+			sum += p.Value * p.Weight
+			weight += p.Weight
+			total.Count += p.Count
+			if ts := p.LastAt.UTC().Format(time.RFC3339); ts > total.Timestamp {
+				total.Timestamp = ts
+			}
+		}
+		total.Value = sum / weight
+		if len(values) == 1 {
+			total = values[0]
+		}
+
+		response := AggregateResponse{
+			Symbol:         symbol,
+			Type:           kind,
+			Period:         period,
+			From:           timeRange.From.Format(time.RFC3339),
+			To:             timeRange.To.Format(time.RFC3339),
+			Source:         "postgres",
+			AggregateValue: total,
+		}
+		if exchange == "" {
+			response.Exchanges = values
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
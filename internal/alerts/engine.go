@@ -0,0 +1,306 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/adapters/storage"
+	"marketflow/internal/domain"
+	"marketflow/internal/stream"
+)
+
+type pricePoint struct {
+	price float64
+	at    time.Time
+}
+
+// ruleState is the per-rule memory needed to detect crossings and moves.
+type ruleState struct {
+	rule      domain.AlertRule
+	lastFired map[string]time.Time    // exchange ("" for cross-venue rules) -> last alert
+	prev      map[string]float64      // exchange -> previous price (price_cross)
+	history   map[string][]pricePoint // exchange -> prices inside Window (percent_move)
+}
+
+// Engine evaluates alert rules against the tick stream and hands fired
+// alerts to the webhook dispatcher.
+type Engine struct {
+	db         *sql.DB
+	hub        *stream.Hub
+	dispatcher *Dispatcher
+	logger     *slog.Logger
+
+	mu       sync.Mutex
+	rules    map[int64]*ruleState
+	latest   map[string]map[string]pricePoint // symbol -> exchange -> last tick
+	expected map[string]time.Time             // exchange -> when it was expected to start ticking
+}
+
+func NewEngine(db *sql.DB, hub *stream.Hub, dispatcher *Dispatcher, logger *slog.Logger) *Engine {
+	return &Engine{
+		db:         db,
+		hub:        hub,
+		dispatcher: dispatcher,
+		logger:     logger.With("component", "alerts"),
+		rules:      make(map[int64]*ruleState),
+		latest:     make(map[string]map[string]pricePoint),
+		expected:   make(map[string]time.Time),
+	}
+}
+
+// SetExchanges lists the configured exchanges, so feed_stale rules also
+// fire for a venue that never ticks. The staleness of such a venue counts
+// from when it was first listed.
+func (e *Engine) SetExchanges(names []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	expected := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		since, ok := e.expected[name]
+		if !ok {
+			since = now
+		}
+		expected[name] = since
+	}
+	e.expected = expected
+}
+
+// Load replaces the in-memory rules with those stored in PostgreSQL.
+func (e *Engine) Load(ctx context.Context) error {
+	rules, err := storage.ListAlertRules(ctx, e.db)
+	if err != nil {
+		return fmt.Errorf("failed to load alert rules: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.rules = make(map[int64]*ruleState, len(rules))
+	for _, r := range rules {
+		e.rules[r.ID] = newRuleState(r)
+	}
+	e.logger.Info("Alert rules loaded", "count", len(rules))
+	return nil
+}
+
+func newRuleState(r domain.AlertRule) *ruleState {
+	return &ruleState{
+		rule:      r,
+		lastFired: make(map[string]time.Time),
+		prev:      make(map[string]float64),
+		history:   make(map[string][]pricePoint),
+	}
+}
+
+// Add persists a new rule and starts evaluating it.
+func (e *Engine) Add(ctx context.Context, r *domain.AlertRule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if err := storage.InsertAlertRule(ctx, e.db, r); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.rules[r.ID] = newRuleState(*r)
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *Engine) Remove(ctx context.Context, id int64) (bool, error) {
+	found, err := storage.DeleteAlertRule(ctx, e.db, id)
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	delete(e.rules, id)
+	e.mu.Unlock()
+	return found, nil
+}
+
+func (e *Engine) Rules() []domain.AlertRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := make([]domain.AlertRule, 0, len(e.rules))
+	for _, st := range e.rules {
+		rules = append(rules, st.rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// Run evaluates ticks as they arrive and checks feed staleness every second.
+func (e *Engine) Run(ctx context.Context) {
+	isTick := func(ev stream.Event) bool { return ev.Type == "tick" }
+	staleTicker := time.NewTicker(time.Second)
+	defer staleTicker.Stop()
+
+	for {
+		sub := e.hub.Subscribe(0, 8192, isTick)
+
+	consume:
+		for {
+			select {
+			case <-ctx.Done():
+				e.hub.Unsubscribe(sub)
+				return
+			case now := <-staleTicker.C:
+				e.checkStale(now)
+			case ev, ok := <-sub.C:
+				if !ok {
+					break consume
+				}
+				if update, ok := ev.Payload.(domain.PriceUpdate); ok {
+					e.onTick(update)
+				}
+			}
+		}
+
+		e.logger.Warn("Alert engine fell behind the tick stream, resubscribing")
+	}
+}
+
+func (e *Engine) onTick(update domain.PriceUpdate) {
+	now := update.ReceivedAt
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	venues, ok := e.latest[update.Symbol]
+	if !ok {
+		venues = make(map[string]pricePoint)
+		e.latest[update.Symbol] = venues
+	}
+	venues[update.Exchange] = pricePoint{price: update.Price, at: now}
+
+	for _, st := range e.rules {
+		r := &st.rule
+		if r.Symbol != update.Symbol || (r.Exchange != "" && r.Exchange != update.Exchange) {
+			continue
+		}
+
+		switch r.Type {
+		case domain.AlertPriceCross:
+			prev, seen := st.prev[update.Exchange]
+			st.prev[update.Exchange] = update.Price
+			if !seen {
+				continue
+			}
+			crossedUp := r.Direction == "above" && prev < r.Level && update.Price >= r.Level
+			crossedDown := r.Direction == "below" && prev > r.Level && update.Price <= r.Level
+			if crossedUp || crossedDown {
+				e.fire(st, update.Exchange, update.Price, r.Level, now,
+					fmt.Sprintf("%s on %s crossed %s %g at %g", r.Symbol, update.Exchange, r.Direction, r.Level, update.Price))
+			}
+
+		case domain.AlertPercentMove:
+			points := append(st.history[update.Exchange], pricePoint{price: update.Price, at: now})
+			cutoff := now.Add(-r.Window)
+			for len(points) > 0 && points[0].at.Before(cutoff) {
+				points = points[1:]
+			}
+			st.history[update.Exchange] = points
+
+			base := points[0].price
+			if base <= 0 {
+				continue
+			}
+			move := (update.Price - base) / base * 100
+			if math.Abs(move) >= r.Percent {
+				e.fire(st, update.Exchange, update.Price, move, now,
+					fmt.Sprintf("%s on %s moved %.2f%% within %s", r.Symbol, update.Exchange, move, r.Window))
+			}
+
+		case domain.AlertSpreadAbove:
+			high, low := math.Inf(-1), math.Inf(1)
+			var highEx, lowEx string
+			for ex, p := range venues {
+				// Only compare venues that are still ticking
+				if now.Sub(p.at) > 5*time.Second {
+					continue
+				}
+				if p.price > high {
+					high, highEx = p.price, ex
+				}
+				if p.price < low {
+					low, lowEx = p.price, ex
+				}
+			}
+			if highEx == "" || highEx == lowEx || low <= 0 {
+				continue
+			}
+			spread := (high - low) / low * 10000
+			if spread >= r.SpreadBps {
+				e.fire(st, "", update.Price, spread, now,
+					fmt.Sprintf("%s spread %.1f bps between %s and %s", r.Symbol, spread, highEx, lowEx))
+			}
+		}
+	}
+}
+
+func (e *Engine) checkStale(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, st := range e.rules {
+		r := &st.rule
+		if r.Type != domain.AlertFeedStale {
+			continue
+		}
+
+		// Expected venues without a tick yet count as stale since they were listed
+		venues := make(map[string]pricePoint)
+		for ex, since := range e.expected {
+			venues[ex] = pricePoint{at: since}
+		}
+		for ex, p := range e.latest[r.Symbol] {
+			venues[ex] = p
+		}
+		if r.Exchange != "" {
+			p, ok := venues[r.Exchange]
+			if !ok {
+				continue
+			}
+			venues = map[string]pricePoint{r.Exchange: p}
+		}
+
+		for ex, p := range venues {
+			if age := now.Sub(p.at); age >= r.StaleAfter {
+				e.fire(st, ex, p.price, age.Seconds(), now,
+					fmt.Sprintf("%s on %s has not ticked for %s", r.Symbol, ex, age.Truncate(time.Second)))
+			}
+		}
+	}
+}
+
+// fire enqueues an alert unless the rule is still cooling down for that
+// exchange. Callers hold e.mu.
+func (e *Engine) fire(st *ruleState, exchange string, price, value float64, now time.Time, message string) {
+	if last, ok := st.lastFired[exchange]; ok && now.Sub(last) < st.rule.Cooldown {
+		return
+	}
+	st.lastFired[exchange] = now
+
+	alert := domain.Alert{
+		RuleID:   st.rule.ID,
+		Type:     st.rule.Type,
+		Symbol:   st.rule.Symbol,
+		Exchange: exchange,
+		Price:    price,
+		Value:    value,
+		Message:  message,
+		FiredAt:  now.UTC(),
+	}
+	e.logger.Info("Alert fired", "rule", alert.RuleID, "message", message)
+	e.dispatcher.Enqueue(st.rule, alert)
+}
@@ -0,0 +1,172 @@
+package alerts
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+const (
+	SignatureHeader = "X-Marketflow-Signature"
+	TimestampHeader = "X-Marketflow-Timestamp"
+)
+
+type delivery struct {
+	rule  domain.AlertRule
+	alert domain.Alert
+}
+
+type webhookPayload struct {
+	RuleID   int64   `json:"rule_id"`
+	Type     string  `json:"type"`
+	Symbol   string  `json:"symbol"`
+	Exchange string  `json:"exchange,omitempty"`
+	Price    float64 `json:"price"`
+	Value    float64 `json:"value"`
+	Message  string  `json:"message"`
+	FiredAt  string  `json:"fired_at"`
+}
+
+// Dispatcher posts alerts to their webhooks from a small worker pool,
+// retrying failed deliveries with exponential backoff.
+type Dispatcher struct {
+	client     *http.Client
+	queue      chan delivery
+	maxRetries int
+	backoff    time.Duration
+	logger     *slog.Logger
+}
+
+// DispatcherConfig sizes the dispatcher. Zero fields other than MaxRetries
+// get defaults.
+type DispatcherConfig struct {
+	Workers   int
+	QueueSize int
+	Timeout   time.Duration
+	// MaxRetries is how many times a failed delivery is retried, with the
+	// wait starting at Backoff and doubling each time.
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+func NewDispatcher(cfg DispatcherConfig, logger *slog.Logger) *Dispatcher {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = time.Second
+	}
+
+	d := &Dispatcher{
+		client:     &http.Client{Timeout: cfg.Timeout},
+		queue:      make(chan delivery, cfg.QueueSize),
+		maxRetries: max(cfg.MaxRetries, 0),
+		backoff:    cfg.Backoff,
+		logger:     logger.With("component", "webhooks"),
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// Enqueue never blocks the evaluation loop; alerts are dropped when the queue is full.
+func (d *Dispatcher) Enqueue(rule domain.AlertRule, alert domain.Alert) {
+	select {
+	case d.queue <- delivery{rule: rule, alert: alert}:
+	default:
+		d.logger.Warn("Webhook queue full, dropping alert", "rule", rule.ID)
+	}
+}
+
+func (d *Dispatcher) worker() {
+	for job := range d.queue {
+		d.deliver(job)
+	}
+}
+
+func (d *Dispatcher) deliver(job delivery) {
+	body, err := json.Marshal(webhookPayload{
+		RuleID:   job.alert.RuleID,
+		Type:     job.alert.Type,
+		Symbol:   job.alert.Symbol,
+		Exchange: job.alert.Exchange,
+		Price:    job.alert.Price,
+		Value:    job.alert.Value,
+		Message:  job.alert.Message,
+		FiredAt:  job.alert.FiredAt.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		d.logger.Error("Failed to encode alert", "rule", job.rule.ID, "error", err)
+		return
+	}
+
+	wait := d.backoff
+	for attempt := 0; ; attempt++ {
+		err := d.post(job.rule, body)
+		if err == nil {
+			return
+		}
+		if attempt >= d.maxRetries {
+			d.logger.Error("Webhook delivery failed", "rule", job.rule.ID, "attempts", attempt+1, "error", err)
+			return
+		}
+		d.logger.Warn("Webhook delivery failed, retrying", "rule", job.rule.ID, "attempt", attempt+1, "error", err)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.body" so receivers can
+// verify the payload and reject replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) post(rule domain.AlertRule, body []byte) error {
+	// The client timeout bounds the whole request
+	req, err := http.NewRequest(http.MethodPost, rule.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	if rule.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(rule.Secret, timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	// Drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package alerts
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type received struct {
+	at        time.Time
+	body      []byte
+	timestamp string
+	signature string
+}
+
+// webhookServer answers the first failures requests with 500 and the rest
+// with 204, recording every request.
+func webhookServer(t *testing.T, failures int) (*httptest.Server, func() []received) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []received
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, received{
+			at:        time.Now(),
+			body:      body,
+			timestamp: r.Header.Get(TimestampHeader),
+			signature: r.Header.Get(SignatureHeader),
+		})
+		n := len(got)
+		mu.Unlock()
+
+		if n <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]received(nil), got...)
+	}
+}
+
+// waitFor polls until n requests arrived, then waits a little longer to
+// catch any extra ones.
+func waitFor(t *testing.T, requests func() []received, n int) []received {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(requests()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d requests, want %d", len(requests()), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	return requests()
+}
+
+func testAlert() domain.Alert {
+	return domain.Alert{
+		RuleID:   7,
+		Type:     "price_above",
+		Symbol:   "BTCUSDT",
+		Exchange: "binance",
+		Price:    101000,
+		Value:    100000,
+		Message:  "BTCUSDT above 100000",
+		FiredAt:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSign(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`1700000000.{"a":1}`))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign("secret", "1700000000", []byte(`{"a":1}`)); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+	if Sign("secret", "1700000001", []byte(`{"a":1}`)) == want {
+		t.Error("signature does not cover the timestamp")
+	}
+}
+
+func TestDeliverySignedPayload(t *testing.T) {
+	srv, requests := webhookServer(t, 0)
+	d := NewDispatcher(DispatcherConfig{Workers: 1}, discard)
+
+	d.Enqueue(domain.AlertRule{ID: 7, WebhookURL: srv.URL, Secret: "s3cret"}, testAlert())
+	got := waitFor(t, requests, 1)
+	if len(got) != 1 {
+		t.Fatalf("got %d requests, want 1", len(got))
+	}
+	req := got[0]
+
+	if _, err := strconv.ParseInt(req.timestamp, 10, 64); err != nil {
+		t.Errorf("timestamp header %q is not unix seconds", req.timestamp)
+	}
+	if want := "sha256=" + Sign("s3cret", req.timestamp, req.body); req.signature != want {
+		t.Errorf("signature = %q, want %q", req.signature, want)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	want := webhookPayload{
+		RuleID:   7,
+		Type:     "price_above",
+		Symbol:   "BTCUSDT",
+		Exchange: "binance",
+		Price:    101000,
+		Value:    100000,
+		Message:  "BTCUSDT above 100000",
+		FiredAt:  "2024-05-01T12:00:00Z",
+	}
+	if payload != want {
+		t.Errorf("payload = %+v, want %+v", payload, want)
+	}
+}
+
+func TestDeliveryUnsignedWithoutSecret(t *testing.T) {
+	srv, requests := webhookServer(t, 0)
+	d := NewDispatcher(DispatcherConfig{Workers: 1}, discard)
+
+	d.Enqueue(domain.AlertRule{ID: 1, WebhookURL: srv.URL}, testAlert())
+	got := waitFor(t, requests, 1)
+	if got[0].signature != "" {
+		t.Errorf("unsigned rule sent signature %q", got[0].signature)
+	}
+	if got[0].timestamp == "" {
+		t.Error("timestamp header missing")
+	}
+}
+
+func TestDeliveryRetriesWithBackoff(t *testing.T) {
+	srv, requests := webhookServer(t, 2)
+	backoff := 40 * time.Millisecond
+	d := NewDispatcher(DispatcherConfig{Workers: 1, MaxRetries: 3, Backoff: backoff}, discard)
+
+	d.Enqueue(domain.AlertRule{ID: 1, WebhookURL: srv.URL, Secret: "k"}, testAlert())
+	got := waitFor(t, requests, 3)
+	if len(got) != 3 {
+		t.Fatalf("got %d attempts, want 2 failures and 1 success", len(got))
+	}
+
+	// The wait doubles after every failure
+	for i, want := range []time.Duration{backoff, 2 * backoff} {
+		if gap := got[i+1].at.Sub(got[i].at); gap < want {
+			t.Errorf("retry %d came after %s, want at least %s", i+1, gap, want)
+		}
+	}
+	// Every attempt is signed afresh with its own timestamp
+	for i, req := range got {
+		if want := "sha256=" + Sign("k", req.timestamp, req.body); req.signature != want {
+			t.Errorf("attempt %d signature = %q, want %q", i+1, req.signature, want)
+		}
+	}
+}
+
+func TestDeliveryGivesUpAfterMaxRetries(t *testing.T) {
+	srv, requests := webhookServer(t, 100)
+	d := NewDispatcher(DispatcherConfig{Workers: 1, MaxRetries: 2, Backoff: 5 * time.Millisecond}, discard)
+
+	d.Enqueue(domain.AlertRule{ID: 1, WebhookURL: srv.URL}, testAlert())
+	if got := waitFor(t, requests, 3); len(got) != 3 {
+		t.Errorf("got %d attempts, want 1 plus 2 retries", len(got))
+	}
+}
+
+func TestDeliveryTimeout(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	d := NewDispatcher(DispatcherConfig{Workers: 1, Timeout: 30 * time.Millisecond, MaxRetries: 1, Backoff: 5 * time.Millisecond}, discard)
+	d.Enqueue(domain.AlertRule{ID: 1, WebhookURL: srv.URL}, testAlert())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := attempts
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d attempts, want the timed out request retried once", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
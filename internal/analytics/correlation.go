@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Returns maps a bar start time to the log return from the previous bar.
+type Returns map[time.Time]float64
+
+// LogReturns turns an ordered series of bar closes into log returns. A
+// return is only taken between adjacent bars, so gaps in the data never
+// produce a return spanning several intervals.
+func LogReturns(starts []time.Time, closes []float64, interval time.Duration) Returns {
+	r := make(Returns, len(starts))
+	for i := 1; i < len(starts); i++ {
+		if starts[i].Sub(starts[i-1]) != interval || closes[i-1] <= 0 || closes[i] <= 0 {
+			continue
+		}
+		r[starts[i]] = math.Log(closes[i] / closes[i-1])
+	}
+	return r
+}
+
+// Matrix is a symmetric correlation matrix. A nil cell means the pair did
+// not have enough overlapping returns or one side never moved.
+type Matrix struct {
+	Values  [][]*float64
+	Overlap [][]int
+}
+
+// Pearson returns the correlation of a and b over their common timestamps
+// and the number of timestamps used. ok is false when fewer than minOverlap
+// returns overlap or either series has zero variance.
+func Pearson(a, b Returns, minOverlap int) (corr float64, n int, ok bool) {
+	var sumA, sumB, sumAA, sumBB, sumAB float64
+	for t, x := range a {
+		y, found := b[t]
+		if !found {
+			continue
+		}
+		n++
+		sumA += x
+		sumB += y
+		sumAA += x * x
+		sumBB += y * y
+		sumAB += x * y
+	}
+	if n < minOverlap || n < 2 {
+		return 0, n, false
+	}
+
+	fn := float64(n)
+	cov := sumAB - sumA*sumB/fn
+	varA := sumAA - sumA*sumA/fn
+	varB := sumBB - sumB*sumB/fn
+	if varA <= 0 || varB <= 0 {
+		return 0, n, false
+	}
+
+	corr = cov / math.Sqrt(varA*varB)
+	// Clamp rounding noise so callers never see 1.0000000002
+	return math.Max(-1, math.Min(1, corr)), n, true
+}
+
+// Correlate builds the pairwise correlation matrix of the given series in
+// order.
+func Correlate(series []Returns, minOverlap int) Matrix {
+	size := len(series)
+	m := Matrix{
+		Values:  make([][]*float64, size),
+		Overlap: make([][]int, size),
+	}
+	for i := range series {
+		m.Values[i] = make([]*float64, size)
+		m.Overlap[i] = make([]int, size)
+	}
+
+	for i := 0; i < size; i++ {
+		for j := i; j < size; j++ {
+			corr, n, ok := Pearson(series[i], series[j], minOverlap)
+			m.Overlap[i][j], m.Overlap[j][i] = n, n
+			if !ok {
+				continue
+			}
+			if i == j {
+				corr = 1
+			}
+			v := corr
+			m.Values[i][j], m.Values[j][i] = &v, &v
+		}
+	}
+
+	return m
+}
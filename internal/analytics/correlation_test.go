@@ -0,0 +1,128 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func minutes(n int) []time.Time {
+	starts := make([]time.Time, n)
+	for i := range starts {
+		starts[i] = t0.Add(time.Duration(i) * time.Minute)
+	}
+	return starts
+}
+
+func TestLogReturns(t *testing.T) {
+	// The 12:03 bar is missing, so no return spans 12:02 to 12:04
+	m := minutes(5)
+	starts := []time.Time{m[0], m[1], m[2], m[4]}
+	closes := []float64{100, 110, 99, 120}
+
+	r := LogReturns(starts, closes, time.Minute)
+	want := Returns{
+		t0.Add(1 * time.Minute): math.Log(110.0 / 100),
+		t0.Add(2 * time.Minute): math.Log(99.0 / 110),
+	}
+	if len(r) != len(want) {
+		t.Fatalf("got %d returns %v, want %d", len(r), r, len(want))
+	}
+	for at, w := range want {
+		if got, ok := r[at]; !ok || math.Abs(got-w) > 1e-15 {
+			t.Errorf("return at %s = %g, want %g", at.Format("15:04"), got, w)
+		}
+	}
+}
+
+func TestLogReturnsSkipsNonPositiveCloses(t *testing.T) {
+	r := LogReturns(minutes(4), []float64{100, 0, 100, 101}, time.Minute)
+	if len(r) != 1 {
+		t.Fatalf("got %v, want only the 100 -> 101 return", r)
+	}
+	if got := r[t0.Add(3*time.Minute)]; math.Abs(got-math.Log(1.01)) > 1e-15 {
+		t.Errorf("return = %g, want %g", got, math.Log(1.01))
+	}
+}
+
+func returns(values ...float64) Returns {
+	r := make(Returns, len(values))
+	for i, v := range values {
+		r[t0.Add(time.Duration(i)*time.Minute)] = v
+	}
+	return r
+}
+
+func TestPearson(t *testing.T) {
+	a := returns(0.01, -0.02, 0.015, 0.003, -0.007)
+
+	scaled := make(Returns, len(a))
+	negated := make(Returns, len(a))
+	for at, v := range a {
+		scaled[at] = 3*v + 0.001
+		negated[at] = -2 * v
+	}
+
+	tests := []struct {
+		name   string
+		a, b   Returns
+		min    int
+		want   float64
+		wantN  int
+		wantOK bool
+	}{
+		{"perfectly correlated", a, scaled, 3, 1, 5, true},
+		{"perfectly anti-correlated", a, negated, 3, -1, 5, true},
+		{"uncorrelated", returns(1, -1, 1, -1), returns(1, 1, -1, -1), 2, 0, 4, true},
+		{"too little overlap", a, returns(0.01, 0.02), 3, 0, 2, false},
+		{"flat series", a, returns(0, 0, 0, 0, 0), 3, 0, 5, false},
+	}
+	for _, tt := range tests {
+		corr, n, ok := Pearson(tt.a, tt.b, tt.min)
+		if ok != tt.wantOK || n != tt.wantN {
+			t.Errorf("%s: n=%d ok=%v, want n=%d ok=%v", tt.name, n, ok, tt.wantN, tt.wantOK)
+			continue
+		}
+		if ok && math.Abs(corr-tt.want) > 1e-12 {
+			t.Errorf("%s: corr = %.15f, want %g", tt.name, corr, tt.want)
+		}
+	}
+}
+
+func TestPearsonUsesOnlyCommonTimestamps(t *testing.T) {
+	a := returns(0.01, 0.02, 0.03, 0.04)
+	b := returns(0.01, 0.02, 0.03, 0.04)
+	// A wild return that only b has must not count
+	b[t0.Add(time.Hour)] = 5
+
+	corr, n, ok := Pearson(a, b, 2)
+	if !ok || n != 4 || math.Abs(corr-1) > 1e-12 {
+		t.Errorf("got corr=%g n=%d ok=%v, want 1 over 4 returns", corr, n, ok)
+	}
+}
+
+func TestCorrelate(t *testing.T) {
+	a := returns(0.01, -0.02, 0.015, 0.003)
+	b := make(Returns, len(a))
+	for at, v := range a {
+		b[at] = -v
+	}
+	flat := returns(0, 0, 0, 0)
+
+	m := Correlate([]Returns{a, b, flat}, 3)
+
+	if m.Values[0][0] == nil || *m.Values[0][0] != 1 {
+		t.Errorf("diagonal = %v, want 1", m.Values[0][0])
+	}
+	if m.Values[0][1] == nil || math.Abs(*m.Values[0][1]+1) > 1e-12 || m.Values[1][0] != m.Values[0][1] {
+		t.Errorf("a/b cell = %v / %v, want a shared -1", m.Values[0][1], m.Values[1][0])
+	}
+	if m.Values[0][2] != nil || m.Values[2][2] != nil {
+		t.Error("a series that never moved has a correlation")
+	}
+	if m.Overlap[0][2] != 4 || m.Overlap[2][0] != 4 {
+		t.Errorf("overlap = %d / %d, want 4", m.Overlap[0][2], m.Overlap[2][0])
+	}
+}
@@ -0,0 +1,279 @@
+package arbitrage
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/adapters/storage"
+	"marketflow/internal/domain"
+	"marketflow/internal/stream"
+)
+
+type Config struct {
+	// ThresholdBps is the minimum spread after fees, in basis points.
+	ThresholdBps float64
+	// FeesBps is the taker fee per exchange; DefaultFeeBps applies to the rest.
+	FeesBps       map[string]float64
+	DefaultFeeBps float64
+	// MinDuration is how long the spread must persist before it is reported.
+	MinDuration time.Duration
+	// MaxQuoteAge excludes venues that have not ticked recently.
+	MaxQuoteAge time.Duration
+}
+
+type quote struct {
+	price float64
+	at    time.Time
+}
+
+// Detector watches the tick stream for sustained cross-exchange spreads,
+// persists them to arbitrage_opportunities and publishes "arbitrage" events.
+type Detector struct {
+	cfg    Config
+	db     *sql.DB
+	hub    *stream.Hub
+	logger *slog.Logger
+
+	mu         sync.Mutex
+	quotes     map[string]map[string]quote
+	candidates map[string]*domain.ArbitrageOpportunity
+
+	writes chan write
+}
+
+// write is a detected or closed opportunity waiting for PostgreSQL. The
+// candidate pointer identifies the opportunity across its two writes.
+type write struct {
+	candidate *domain.ArbitrageOpportunity
+	snapshot  domain.ArbitrageOpportunity
+	closed    bool
+}
+
+func NewDetector(cfg Config, db *sql.DB, hub *stream.Hub, logger *slog.Logger) *Detector {
+	return &Detector{
+		cfg:        cfg,
+		db:         db,
+		hub:        hub,
+		logger:     logger.With("component", "arbitrage"),
+		quotes:     make(map[string]map[string]quote),
+		candidates: make(map[string]*domain.ArbitrageOpportunity),
+		writes:     make(chan write, 1024),
+	}
+}
+
+// SetConfig applies new thresholds from the next tick on. Open candidates
+// are re-evaluated against them when their symbol ticks again.
+func (d *Detector) SetConfig(cfg Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cfg = cfg
+}
+
+// Run consumes ticks until ctx is cancelled, resubscribing if the hub
+// evicts the detector for falling behind.
+func (d *Detector) Run(ctx context.Context) {
+	isTick := func(ev stream.Event) bool { return ev.Type == "tick" }
+	go d.writeLoop(ctx)
+
+	for {
+		sub := d.hub.Subscribe(0, 8192, isTick)
+
+	consume:
+		for {
+			select {
+			case <-ctx.Done():
+				d.hub.Unsubscribe(sub)
+				return
+			case ev, ok := <-sub.C:
+				if !ok {
+					break consume
+				}
+				if update, ok := ev.Payload.(domain.PriceUpdate); ok {
+					d.onTick(update)
+				}
+			}
+		}
+
+		d.logger.Warn("Arbitrage detector fell behind the tick stream, resubscribing")
+	}
+}
+
+// Active returns the opportunities currently being reported.
+func (d *Detector) Active() []domain.ArbitrageOpportunity {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var active []domain.ArbitrageOpportunity
+	for _, o := range d.candidates {
+		if !o.DetectedAt.IsZero() {
+			active = append(active, *o)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].DetectedAt.After(active[j].DetectedAt) })
+	return active
+}
+
+func (d *Detector) fee(exchange string) float64 {
+	if fee, ok := d.cfg.FeesBps[exchange]; ok {
+		return fee
+	}
+	return d.cfg.DefaultFeeBps
+}
+
+func (d *Detector) onTick(update domain.PriceUpdate) {
+	if update.Price <= 0 {
+		return
+	}
+	now := update.ReceivedAt
+
+	d.mu.Lock()
+
+	venues, ok := d.quotes[update.Symbol]
+	if !ok {
+		venues = make(map[string]quote)
+		d.quotes[update.Symbol] = venues
+	}
+	venues[update.Exchange] = quote{price: update.Price, at: now}
+
+	// Every fresh buy-low/sell-high pair clearing the threshold after fees
+	profitable := make(map[string]domain.ArbitrageOpportunity)
+	for buyEx, buy := range venues {
+		if now.Sub(buy.at) > d.cfg.MaxQuoteAge {
+			continue
+		}
+		for sellEx, sell := range venues {
+			if sellEx == buyEx || sell.price <= buy.price || now.Sub(sell.at) > d.cfg.MaxQuoteAge {
+				continue
+			}
+			spread := (sell.price - buy.price) / buy.price * 10000
+			net := spread - d.fee(buyEx) - d.fee(sellEx)
+			if net < d.cfg.ThresholdBps {
+				continue
+			}
+			profitable[update.Symbol+":"+buyEx+":"+sellEx] = domain.ArbitrageOpportunity{
+				Symbol:       update.Symbol,
+				BuyExchange:  buyEx,
+				SellExchange: sellEx,
+				BuyPrice:     buy.price,
+				SellPrice:    sell.price,
+				SpreadBps:    spread,
+				NetSpreadBps: net,
+			}
+		}
+	}
+
+	var detected, closed []*domain.ArbitrageOpportunity
+	for key, o := range d.candidates {
+		if o.Symbol != update.Symbol {
+			continue
+		}
+		if _, still := profitable[key]; still {
+			continue
+		}
+		if !o.DetectedAt.IsZero() {
+			ended := now
+			o.EndedAt = &ended
+			closed = append(closed, o)
+		}
+		delete(d.candidates, key)
+	}
+
+	for key, p := range profitable {
+		o, ok := d.candidates[key]
+		if !ok {
+			p.StartedAt = now
+			p.MaxNetSpreadBps = p.NetSpreadBps
+			o = &p
+			d.candidates[key] = o
+		} else {
+			o.BuyPrice, o.SellPrice = p.BuyPrice, p.SellPrice
+			o.SpreadBps, o.NetSpreadBps = p.SpreadBps, p.NetSpreadBps
+			if p.NetSpreadBps > o.MaxNetSpreadBps {
+				o.MaxNetSpreadBps = p.NetSpreadBps
+			}
+		}
+
+		if o.DetectedAt.IsZero() && now.Sub(o.StartedAt) >= d.cfg.MinDuration {
+			o.DetectedAt = now
+			detected = append(detected, o)
+		}
+	}
+
+	// Snapshot under the lock, hand over to writeLoop without it
+	detectedCopies := make([]domain.ArbitrageOpportunity, len(detected))
+	for i, o := range detected {
+		detectedCopies[i] = *o
+	}
+	closedCopies := make([]domain.ArbitrageOpportunity, len(closed))
+	for i, o := range closed {
+		closedCopies[i] = *o
+	}
+	d.mu.Unlock()
+
+	for i, o := range detectedCopies {
+		d.logger.Info("Arbitrage opportunity detected",
+			"symbol", o.Symbol, "buy", o.BuyExchange, "sell", o.SellExchange, "net_bps", o.NetSpreadBps)
+		d.enqueue(write{candidate: detected[i], snapshot: o})
+	}
+	for i, o := range closedCopies {
+		d.enqueue(write{candidate: closed[i], snapshot: o, closed: true})
+	}
+}
+
+// enqueue never blocks the tick loop; a full queue means PostgreSQL is far
+// behind, and the opportunity is published without being stored.
+func (d *Detector) enqueue(w write) {
+	select {
+	case d.writes <- w:
+	default:
+		d.logger.Error("Arbitrage write queue full, not storing opportunity", "symbol", w.snapshot.Symbol)
+		d.hub.Publish("arbitrage", "", w.snapshot.Symbol, w.snapshot)
+	}
+}
+
+// writeLoop stores opportunities in order and publishes them once they
+// have their ID, so a slow database only delays events instead of stalling
+// the detector's hub subscription.
+func (d *Detector) writeLoop(ctx context.Context) {
+	ids := make(map[*domain.ArbitrageOpportunity]int64)
+
+	for {
+		var w write
+		select {
+		case <-ctx.Done():
+			return
+		case w = <-d.writes:
+		}
+
+		o := &w.snapshot
+		if !w.closed {
+			d.persist(o, storage.InsertOpportunity)
+			if o.ID > 0 {
+				ids[w.candidate] = o.ID
+				d.mu.Lock()
+				w.candidate.ID = o.ID
+				d.mu.Unlock()
+			}
+		} else {
+			o.ID = ids[w.candidate]
+			delete(ids, w.candidate)
+			if o.ID > 0 {
+				d.persist(o, storage.CloseOpportunity)
+			}
+		}
+		d.hub.Publish("arbitrage", "", o.Symbol, *o)
+	}
+}
+
+func (d *Detector) persist(o *domain.ArbitrageOpportunity, fn func(context.Context, *sql.DB, *domain.ArbitrageOpportunity) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := fn(ctx, d.db, o); err != nil {
+		d.logger.Error("Failed to persist arbitrage opportunity", "symbol", o.Symbol, "error", err)
+	}
+}
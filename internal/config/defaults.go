@@ -0,0 +1,113 @@
+package config
+
+import "fmt"
+
+// DefaultExchanges are the exchange simulators from docker-compose.
+var DefaultExchanges = []ExchangeCfg{
+	{Name: "binance", Addr: "exchange1:40101"},
+	{Name: "coinbase", Addr: "exchange2:40102"},
+	{Name: "kucoin", Addr: "exchange3:40103"},
+}
+
+// positionalNames name exchanges given as bare addresses, matching what the
+// list of strings meant before entries had names.
+var positionalNames = []string{"binance", "coinbase", "kucoin"}
+
+func setDefault[T comparable](field *T, value T) {
+	var zero T
+	if *field == zero {
+		*field = value
+	}
+}
+
+// applyDefaults fills every field left empty by the file and environment.
+func (c *Config) applyDefaults() {
+	setDefault(&c.Mode, "live")
+	setDefault(&c.LogLevel, "info")
+
+	setDefault(&c.Server.Port, 8080)
+	setDefault(&c.Server.ReadTimeout, "10s")
+	setDefault(&c.Server.WriteTimeout, "10s")
+	setDefault(&c.Server.IdleTimeout, "60s")
+	setDefault(&c.Server.ShutdownTimeout, "5s")
+
+	setDefault(&c.Postgres.Port, 5432)
+	setDefault(&c.Postgres.SSLMode, "disable")
+	setDefault(&c.Postgres.MaxOpenConns, 25)
+	setDefault(&c.Postgres.MaxIdleConns, 10)
+	setDefault(&c.Postgres.ConnMaxLife, "5m")
+	setDefault(&c.Postgres.ConnectRetries, 10)
+	setDefault(&c.Postgres.RetryInterval, "2s")
+
+	setDefault(&c.Redis.Port, 6379)
+	setDefault(&c.Redis.ClientName, "marketflow")
+	setDefault(&c.Redis.PoolSize, 50)
+	setDefault(&c.Redis.MaxIdle, min(c.Redis.PoolSize, 10))
+	setDefault(&c.Redis.BreakerThreshold, 5)
+	setDefault(&c.Redis.BreakerCooldown, "5s")
+	setDefault(&c.Redis.PriceRetention, "2m")
+	setDefault(&c.Redis.CommandTimeout, "100ms")
+	if len(c.Redis.SentinelAddrs) > 0 {
+		setDefault(&c.Redis.MasterName, "mymaster")
+	}
+
+	if len(c.Exchanges) == 0 {
+		c.Exchanges = append([]ExchangeCfg(nil), DefaultExchanges...)
+	}
+	for i := range c.Exchanges {
+		if c.Exchanges[i].Name != "" {
+			continue
+		}
+		if i < len(positionalNames) {
+			c.Exchanges[i].Name = positionalNames[i]
+		} else {
+			c.Exchanges[i].Name = fmt.Sprintf("exchange%d", i+1)
+		}
+	}
+
+	setDefault(&c.Ingestion.FanInBuffer, 10000)
+	setDefault(&c.Ingestion.RedisBuffer, 10000)
+	setDefault(&c.Ingestion.PostgresBuffer, 20000)
+	setDefault(&c.Ingestion.QuarantineBuffer, 1000)
+	setDefault(&c.Ingestion.RedisWorkers, 20)
+	setDefault(&c.Ingestion.PoolWorkers, 10)
+	setDefault(&c.Ingestion.AggregateEvery, 5)
+	setDefault(&c.Ingestion.AggregateInterval, "500ms")
+	setDefault(&c.Ingestion.BatchSize, 100)
+	setDefault(&c.Ingestion.FlushInterval, "1s")
+	setDefault(&c.Ingestion.ReconnectDelay, "5s")
+
+	setDefault(&c.Stream.HistorySize, 4096)
+
+	setDefault(&c.Arbitrage.ThresholdBps, 10)
+	setDefault(&c.Arbitrage.MinDuration, "2s")
+	setDefault(&c.Arbitrage.MaxQuoteAge, "5s")
+
+	setDefault(&c.Validation.Window, 50)
+	setDefault(&c.Validation.MinSamples, min(10, c.Validation.Window))
+	setDefault(&c.Validation.ConsensusAge, "5s")
+
+	if len(c.Stats.Windows) == 0 {
+		c.Stats.Windows = []Duration{"1m", "5m", "15m", "1h"}
+	}
+
+	setDefault(&c.Feeds.StaleAfter, "30s")
+	setDefault(&c.Feeds.GapAfter, "10s")
+	setDefault(&c.Feeds.MaxErrorRatio, 0.05)
+	if len(c.Feeds.Symbols) == 0 {
+		c.Feeds.Symbols = c.Validation.Symbols
+	}
+
+	setDefault(&c.Alerts.WebhookWorkers, 4)
+	setDefault(&c.Alerts.WebhookQueue, 1000)
+	setDefault(&c.Alerts.WebhookTimeout, "5s")
+	setDefault(&c.Alerts.WebhookRetries, 3)
+	setDefault(&c.Alerts.WebhookBackoff, "1s")
+
+	setDefault(&c.Health.CacheTTL, "2s")
+	setDefault(&c.Health.SlowPostgres, "500ms")
+	setDefault(&c.Health.QueueHighWater, 0.8)
+	setDefault(&c.Health.FlushStaleAfter, "30s")
+
+	setDefault(&c.Reload.Interval, "5s")
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change is one setting that differs between two configs, named by its
+// JSON path, e.g. "arbitrage.threshold_bps".
+type Change struct {
+	Path string
+	Old  string
+	New  string
+	// Restart is set for settings that only take effect on restart.
+	Restart bool
+}
+
+// reloadable lists the settings a running process applies on reload, as
+// exact paths or section prefixes ending in a dot.
+var reloadable = []string{
+	"mode",
+	"log_level",
+	"exchanges",
+	"redis.price_retention",
+	"ingestion.redis_workers",
+	"ingestion.pool_workers",
+	"ingestion.aggregate_every",
+	"arbitrage.",
+	"validation.",
+	"feeds.",
+	"reload.",
+}
+
+func isReloadable(path string) bool {
+	for _, p := range reloadable {
+		if path == p || (strings.HasSuffix(p, ".") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecret reports paths whose values must not be logged or served.
+// DSN URLs count because they may carry a password.
+func isSecret(path string) bool {
+	return strings.HasSuffix(path, "password") || strings.HasSuffix(path, ".url")
+}
+
+// Diff lists the settings that differ between old and new, in the order
+// they appear in Config.
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	diffValues(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), "", &changes)
+	return changes
+}
+
+func diffValues(old, new reflect.Value, prefix string, changes *[]Change) {
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+		a, b := old.Field(i), new.Field(i)
+
+		if a.Kind() == reflect.Struct {
+			diffValues(a, b, path+".", changes)
+			continue
+		}
+		if reflect.DeepEqual(a.Interface(), b.Interface()) {
+			continue
+		}
+
+		change := Change{Path: path, Restart: !isReloadable(path)}
+		if isSecret(path) {
+			change.Old, change.New = "***", "***"
+		} else {
+			change.Old, change.New = fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface())
+		}
+		*changes = append(*changes, change)
+	}
+}
+
+// Redacted returns a copy that is safe to log or serve, with passwords and
+// DSN URLs masked.
+func (c *Config) Redacted() *Config {
+	r := *c
+	mask := func(s *string) {
+		if *s != "" {
+			*s = "***"
+		}
+	}
+	mask(&r.Postgres.Password)
+	mask(&r.Postgres.URL)
+	mask(&r.Redis.Password)
+	mask(&r.Redis.SentinelPassword)
+	mask(&r.Redis.URL)
+	r.problems = nil
+	return &r
+}
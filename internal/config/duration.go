@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// Duration is a duration written as a Go duration string ("1m30s"). It
+// stays a string so a bad value is reported by Validate with every other
+// problem instead of failing the whole parse.
+type Duration string
+
+// Value returns the parsed duration, zero when empty or invalid.
+func (d Duration) Value() time.Duration {
+	v, _ := time.ParseDuration(string(d))
+	return v
+}
+
+func (d Duration) valid() bool {
+	v, err := time.ParseDuration(string(d))
+	return err == nil && v > 0
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const envPrefix = "MARKETFLOW_"
+
+// legacyEnv maps variables set by docker-compose and older deployments to
+// the MARKETFLOW_ name they stand for. The MARKETFLOW_ name wins when both
+// are set.
+var legacyEnv = map[string]string{
+	"POSTGRES_URL":    "MARKETFLOW_POSTGRES_URL",
+	"REDIS_ADDR":      "MARKETFLOW_REDIS_ADDR",
+	"REDIS_URL":       "MARKETFLOW_REDIS_URL",
+	"REDIS_POOL_SIZE": "MARKETFLOW_REDIS_POOL_SIZE",
+}
+
+// applyEnv overrides fields from MARKETFLOW_<SECTION>_<FIELD> variables
+// named after the JSON keys, e.g. MARKETFLOW_POSTGRES_HOST or
+// MARKETFLOW_INGESTION_BATCH_SIZE. Lists are comma separated, exchanges as
+// name=host:port; maps cannot be set this way.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) []string {
+	withLegacy := func(key string) (string, bool) {
+		if v, ok := lookup(key); ok {
+			return v, true
+		}
+		for legacy, name := range legacyEnv {
+			if name == key {
+				return lookup(legacy)
+			}
+		}
+		return "", false
+	}
+
+	var problems []string
+	setFromEnv(reflect.ValueOf(c).Elem(), envPrefix, withLegacy, &problems)
+	return problems
+}
+
+func setFromEnv(v reflect.Value, prefix string, lookup func(string) (string, bool), problems *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		field := v.Field(i)
+		key := prefix + strings.ToUpper(name)
+
+		if field.Kind() == reflect.Struct {
+			setFromEnv(field, key+"_", lookup, problems)
+			continue
+		}
+
+		raw, ok := lookup(key)
+		if !ok {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		list := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			elem := list.Index(i)
+			if u, ok := elem.Addr().Interface().(encoding.TextUnmarshaler); ok {
+				if err := u.UnmarshalText([]byte(item)); err != nil {
+					return err
+				}
+				continue
+			}
+			if elem.Kind() != reflect.String {
+				return fmt.Errorf("unsupported list type")
+			}
+			elem.SetString(item)
+		}
+		field.Set(list)
+	default:
+		return fmt.Errorf("cannot be set from the environment")
+	}
+	return nil
+}
@@ -6,6 +6,9 @@ import (
 	"os"
 )
 
+// LoadConfig reads the JSON file at path, applies MARKETFLOW_* environment
+// overrides, expands DSN URLs and fills defaults. It does not validate, so
+// callers can apply command-line overrides first; see Validate.
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -17,5 +20,10 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("cannot parse config: %w", err)
 	}
 
+	// Bad overrides are reported by Validate along with everything else
+	cfg.problems = cfg.applyEnv(os.LookupEnv)
+	cfg.problems = append(cfg.problems, cfg.applyURLs()...)
+	cfg.applyDefaults()
+
 	return &cfg, nil
 }
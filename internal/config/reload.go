@@ -0,0 +1,167 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reload outcomes.
+const (
+	ReloadApplied   = "applied"
+	ReloadUnchanged = "unchanged"
+	ReloadRejected  = "rejected"
+)
+
+// maxReloadHistory bounds the results kept for the admin endpoint.
+const maxReloadHistory = 20
+
+// ReloadResult records one reload attempt.
+type ReloadResult struct {
+	At time.Time
+	// Trigger is what asked for the reload: "sighup", "watch" or "admin".
+	Trigger string
+	Status  string
+	Changes []Change
+	Err     error
+}
+
+// Reloader re-reads the config file on demand or when its modification time
+// changes, validates it and hands the settings that differ to apply. An
+// invalid file is rejected as a whole and the running config is kept.
+type Reloader struct {
+	path   string
+	load   func() (*Config, error)
+	apply  func(old, new *Config, changes []Change)
+	logger *slog.Logger
+
+	started *Config
+
+	mu      sync.Mutex
+	current *Config
+	modTime time.Time
+	history []ReloadResult
+}
+
+// NewReloader takes the config the process started with. load must return
+// a validated config; apply runs with reloads serialised and receives every
+// change, including those flagged Restart, which it is expected to skip.
+func NewReloader(path string, current *Config, load func() (*Config, error), apply func(old, new *Config, changes []Change), logger *slog.Logger) *Reloader {
+	r := &Reloader{
+		path:    path,
+		load:    load,
+		apply:   apply,
+		logger:  logger.With("component", "config"),
+		started: current,
+		current: current,
+	}
+	if info, err := os.Stat(path); err == nil {
+		r.modTime = info.ModTime()
+	}
+	return r
+}
+
+// Current returns the last config loaded successfully. Settings listed by
+// PendingRestart are not in effect yet.
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// PendingRestart lists settings changed since startup that need a restart.
+func (r *Reloader) PendingRestart() []Change {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pending []Change
+	for _, ch := range Diff(r.started, r.current) {
+		if ch.Restart {
+			pending = append(pending, ch)
+		}
+	}
+	return pending
+}
+
+// History returns past reloads, newest first.
+func (r *Reloader) History() []ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]ReloadResult, len(r.history))
+	for i, res := range r.history {
+		out[len(out)-1-i] = res
+	}
+	return out
+}
+
+// Reload loads the file now and applies whatever changed.
+func (r *Reloader) Reload(trigger string) ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if info, err := os.Stat(r.path); err == nil {
+		r.modTime = info.ModTime()
+	}
+	res := ReloadResult{At: time.Now(), Trigger: trigger}
+
+	cfg, err := r.load()
+	switch {
+	case err != nil:
+		res.Status, res.Err = ReloadRejected, err
+		r.logger.Error("Config reload rejected, keeping the running config", "trigger", trigger, "error", err)
+	default:
+		res.Changes = Diff(r.current, cfg)
+		if len(res.Changes) == 0 {
+			res.Status = ReloadUnchanged
+			r.logger.Info("Config reloaded, nothing changed", "trigger", trigger)
+			break
+		}
+
+		for _, ch := range res.Changes {
+			r.logger.Info("Config setting changed",
+				"path", ch.Path, "old", ch.Old, "new", ch.New, "restart_required", ch.Restart)
+		}
+		r.apply(r.current, cfg, res.Changes)
+		r.current = cfg
+		res.Status = ReloadApplied
+		r.logger.Info("Config reload applied", "trigger", trigger, "changes", len(res.Changes))
+	}
+
+	r.history = append(r.history, res)
+	if len(r.history) > maxReloadHistory {
+		r.history = r.history[len(r.history)-maxReloadHistory:]
+	}
+	return res
+}
+
+// Watch polls the file's modification time at reload.interval while
+// reload.watch is on, until ctx is cancelled. Both settings are re-read
+// after every reload.
+func (r *Reloader) Watch(ctx context.Context) {
+	for {
+		cfg := r.Current()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cfg.Reload.Interval.Value()):
+		}
+		if !cfg.Reload.Watch {
+			continue
+		}
+
+		info, err := os.Stat(r.path)
+		if err != nil {
+			r.logger.Debug("Cannot stat config file", "path", r.path, "error", err)
+			continue
+		}
+		r.mu.Lock()
+		modified := !info.ModTime().Equal(r.modTime)
+		r.mu.Unlock()
+		if modified {
+			r.Reload("watch")
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// applyURLs expands the Postgres URL and the Redis URL or address into the
+// individual fields.
+func (c *Config) applyURLs() []string {
+	var problems []string
+	if c.Postgres.URL != "" {
+		if err := c.Postgres.parseURL(); err != nil {
+			problems = append(problems, "postgres.url: "+err.Error())
+		}
+	}
+	switch {
+	case c.Redis.URL != "":
+		if err := c.Redis.parseURL(); err != nil {
+			problems = append(problems, "redis.url: "+err.Error())
+		}
+	case c.Redis.Addr != "":
+		host, port, err := splitHostPort(c.Redis.Addr)
+		if err != nil {
+			problems = append(problems, "redis.addr: "+err.Error())
+		} else {
+			c.Redis.Host, c.Redis.Port = host, port
+		}
+	}
+	return problems
+}
+
+func (p *PostgresCfg) parseURL() error {
+	u, err := parseURL(p.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return fmt.Errorf("scheme must be postgres or postgresql, got %q", u.Scheme)
+	}
+
+	p.Host = u.Hostname()
+	if port := u.Port(); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return fmt.Errorf("invalid port %q", port)
+		}
+		p.Port = n
+	}
+	if u.User != nil {
+		p.User = u.User.Username()
+		if pass, ok := u.User.Password(); ok {
+			p.Password = pass
+		}
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		p.DBName = db
+	}
+	if mode := u.Query().Get("sslmode"); mode != "" {
+		p.SSLMode = mode
+	}
+	return nil
+}
+
+func (r *RedisCfg) parseURL() error {
+	u, err := parseURL(r.URL)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		r.TLS = true
+	default:
+		return fmt.Errorf("scheme must be redis or rediss, got %q", u.Scheme)
+	}
+
+	r.Host = u.Hostname()
+	if port := u.Port(); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return fmt.Errorf("invalid port %q", port)
+		}
+		r.Port = n
+	}
+	if u.User != nil {
+		// redis://:password@host has no username, only a password
+		if name := u.User.Username(); name != "" {
+			r.Username = name
+		}
+		if pass, ok := u.User.Password(); ok {
+			r.Password = pass
+		}
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil {
+			return fmt.Errorf("invalid database %q", db)
+		}
+		r.DB = n
+	}
+	return nil
+}
+
+// parseURL drops the URL from parse errors, since it carries the password
+// and the error ends up in logs and the admin API.
+func parseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if ue, ok := err.(*url.Error); ok {
+		err = ue.Err
+	}
+	return u, err
+}
+
+func splitHostPort(addr string) (string, int, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("missing host in %q", addr)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return "", 0, fmt.Errorf("invalid port in %q", addr)
+	}
+	return host, n, nil
+}
+
+// DSN is the lib/pq connection string for these settings. Values are
+// quoted so passwords taken from a URL may contain spaces and quotes.
+func (p PostgresCfg) DSN() string {
+	quote := func(v string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+	}
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quote(p.Host), p.Port, quote(p.User), quote(p.Password), quote(p.DBName), quote(p.SSLMode),
+	)
+}
+
+// Address is the host:port Redis is dialled at.
+func (r RedisCfg) Address() string {
+	return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// ValidationError lists every problem found in a config, so one run shows
+// all of them instead of the first.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+type checker struct {
+	problems []string
+}
+
+func (c *checker) addf(format string, args ...interface{}) {
+	c.problems = append(c.problems, fmt.Sprintf(format, args...))
+}
+
+func (c *checker) port(name string, port int) {
+	if port < 1 || port > 65535 {
+		c.addf("%s: %d is not a valid port", name, port)
+	}
+}
+
+func (c *checker) positive(name string, n int) {
+	if n <= 0 {
+		c.addf("%s: must be greater than zero, got %d", name, n)
+	}
+}
+
+func (c *checker) duration(name string, d Duration) {
+	if !d.valid() {
+		c.addf("%s: %q is not a positive duration like 500ms or 2m", name, d)
+	}
+}
+
+func (c *checker) host(name, host string) {
+	if host == "" {
+		c.addf("%s: must not be empty", name)
+	} else if strings.ContainsAny(host, " /") {
+		c.addf("%s: %q is not a valid host", name, host)
+	}
+}
+
+// Validate checks a config with defaults applied.
+func (c *Config) Validate() error {
+	ck := checker{problems: append([]string(nil), c.problems...)}
+
+	if c.Mode != "live" && c.Mode != "test" {
+		ck.addf("mode: %q must be live or test", c.Mode)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		ck.addf("log_level: %q must be debug, info, warn or error", c.LogLevel)
+	}
+
+	ck.port("server.port", c.Server.Port)
+	ck.duration("server.read_timeout", c.Server.ReadTimeout)
+	ck.duration("server.write_timeout", c.Server.WriteTimeout)
+	ck.duration("server.idle_timeout", c.Server.IdleTimeout)
+	ck.duration("server.shutdown_timeout", c.Server.ShutdownTimeout)
+
+	pg := c.Postgres
+	ck.host("postgres.host", pg.Host)
+	ck.port("postgres.port", pg.Port)
+	if pg.User == "" {
+		ck.addf("postgres.user: must not be empty")
+	}
+	if pg.DBName == "" {
+		ck.addf("postgres.dbname: must not be empty")
+	}
+	switch pg.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		ck.addf("postgres.sslmode: %q is not a valid sslmode", pg.SSLMode)
+	}
+	ck.positive("postgres.max_open_conns", pg.MaxOpenConns)
+	if pg.MaxIdleConns < 0 || pg.MaxIdleConns > pg.MaxOpenConns {
+		ck.addf("postgres.max_idle_conns: must be between 0 and max_open_conns (%d)", pg.MaxOpenConns)
+	}
+	ck.duration("postgres.conn_max_lifetime", pg.ConnMaxLife)
+	ck.positive("postgres.connect_retries", pg.ConnectRetries)
+	ck.duration("postgres.retry_interval", pg.RetryInterval)
+
+	rd := c.Redis
+	ck.host("redis.host", rd.Host)
+	ck.port("redis.port", rd.Port)
+	if rd.DB < 0 {
+		ck.addf("redis.db: must not be negative")
+	}
+	ck.positive("redis.pool_size", rd.PoolSize)
+	if rd.MinIdle < 0 || rd.MinIdle > rd.PoolSize {
+		ck.addf("redis.min_idle: must be between 0 and pool_size (%d)", rd.PoolSize)
+	}
+	if rd.MaxIdle < 0 {
+		ck.addf("redis.max_idle: must not be negative")
+	}
+	ck.positive("redis.breaker_threshold", rd.BreakerThreshold)
+	ck.duration("redis.breaker_cooldown", rd.BreakerCooldown)
+	for _, addr := range rd.SentinelAddrs {
+		if _, _, err := splitHostPort(addr); err != nil {
+			ck.addf("redis.sentinel_addrs: %v", err)
+		}
+	}
+	ck.duration("redis.price_retention", rd.PriceRetention)
+	ck.duration("redis.command_timeout", rd.CommandTimeout)
+
+	names := make(map[string]bool, len(c.Exchanges))
+	for _, ex := range c.Exchanges {
+		if names[ex.Name] {
+			ck.addf("exchanges: name %q is used more than once", ex.Name)
+		}
+		names[ex.Name] = true
+		if strings.ContainsAny(ex.Name, " :=") {
+			ck.addf("exchanges: %q is not a valid name", ex.Name)
+		}
+		if _, _, err := splitHostPort(ex.Addr); err != nil {
+			ck.addf("exchanges.%s: %v", ex.Name, err)
+		}
+	}
+
+	in := c.Ingestion
+	ck.positive("ingestion.fan_in_buffer", in.FanInBuffer)
+	ck.positive("ingestion.redis_buffer", in.RedisBuffer)
+	ck.positive("ingestion.postgres_buffer", in.PostgresBuffer)
+	ck.positive("ingestion.quarantine_buffer", in.QuarantineBuffer)
+	ck.positive("ingestion.redis_workers", in.RedisWorkers)
+	ck.positive("ingestion.pool_workers", in.PoolWorkers)
+	ck.positive("ingestion.aggregate_every", in.AggregateEvery)
+	ck.duration("ingestion.aggregate_interval", in.AggregateInterval)
+	ck.positive("ingestion.batch_size", in.BatchSize)
+	ck.duration("ingestion.flush_interval", in.FlushInterval)
+	ck.duration("ingestion.reconnect_delay", in.ReconnectDelay)
+
+	ck.positive("stream.history_size", c.Stream.HistorySize)
+
+	if c.Arbitrage.ThresholdBps <= 0 {
+		ck.addf("arbitrage.threshold_bps: must be greater than zero")
+	}
+	if c.Arbitrage.DefaultFeeBps < 0 {
+		ck.addf("arbitrage.default_fee_bps: must not be negative")
+	}
+	for exchange, fee := range c.Arbitrage.FeesBps {
+		if fee < 0 {
+			ck.addf("arbitrage.fees_bps.%s: must not be negative", exchange)
+		}
+	}
+	ck.duration("arbitrage.min_duration", c.Arbitrage.MinDuration)
+	ck.duration("arbitrage.max_quote_age", c.Arbitrage.MaxQuoteAge)
+
+	v := c.Validation
+	ck.positive("validation.window", v.Window)
+	if v.MinSamples < 1 || v.MinSamples > v.Window {
+		ck.addf("validation.min_samples: must be between 1 and window (%d)", v.Window)
+	}
+	if v.MaxDeviationPct < 0 || v.MaxStdDevs < 0 || v.ConsensusPct < 0 {
+		ck.addf("validation: thresholds must not be negative")
+	}
+	ck.duration("validation.consensus_age", v.ConsensusAge)
+
+	for _, w := range c.Stats.Windows {
+		ck.duration("stats.windows", w)
+	}
+
+	ck.duration("feeds.stale_after", c.Feeds.StaleAfter)
+	ck.duration("feeds.gap_after", c.Feeds.GapAfter)
+	if c.Feeds.MaxErrorRatio <= 0 || c.Feeds.MaxErrorRatio > 1 {
+		ck.addf("feeds.max_error_ratio: must be in (0, 1], got %g", c.Feeds.MaxErrorRatio)
+	}
+
+	al := c.Alerts
+	ck.positive("alerts.webhook_workers", al.WebhookWorkers)
+	ck.positive("alerts.webhook_queue", al.WebhookQueue)
+	ck.duration("alerts.webhook_timeout", al.WebhookTimeout)
+	if al.WebhookRetries < 0 {
+		ck.addf("alerts.webhook_retries: must not be negative")
+	}
+	ck.duration("alerts.webhook_backoff", al.WebhookBackoff)
+
+	ck.duration("health.cache_ttl", c.Health.CacheTTL)
+	ck.duration("health.slow_postgres", c.Health.SlowPostgres)
+	if c.Health.QueueHighWater <= 0 || c.Health.QueueHighWater > 1 {
+		ck.addf("health.queue_high_water: must be in (0, 1], got %g", c.Health.QueueHighWater)
+	}
+	ck.duration("health.flush_stale_after", c.Health.FlushStaleAfter)
+
+	ck.duration("reload.interval", c.Reload.Interval)
+
+	if len(ck.problems) > 0 {
+		return &ValidationError{Problems: ck.problems}
+	}
+	return nil
+}
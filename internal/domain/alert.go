@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	AlertPriceCross  = "price_cross"
+	AlertPercentMove = "percent_move"
+	AlertSpreadAbove = "spread_above"
+	AlertFeedStale   = "feed_stale"
+)
+
+// AlertRule is a user defined condition evaluated against the tick stream.
+// Which fields matter depends on Type:
+//
+//	price_cross  - Level and Direction ("above" or "below")
+//	percent_move - Percent over Window
+//	spread_above - SpreadBps between the highest and lowest venue
+//	feed_stale   - no tick for StaleAfter
+//
+// An empty Exchange matches every exchange.
+type AlertRule struct {
+	ID         int64
+	Type       string
+	Symbol     string
+	Exchange   string
+	Level      float64
+	Direction  string
+	Percent    float64
+	Window     time.Duration
+	SpreadBps  float64
+	StaleAfter time.Duration
+	WebhookURL string
+	Secret     string
+	Cooldown   time.Duration
+	CreatedAt  time.Time
+}
+
+// Alert is a fired rule as delivered to the webhook.
+type Alert struct {
+	RuleID   int64
+	Type     string
+	Symbol   string
+	Exchange string
+	Price    float64
+	Value    float64
+	Message  string
+	FiredAt  time.Time
+}
+
+func (r *AlertRule) Validate() error {
+	if r.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+
+	u, err := url.Parse(r.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("webhook_url must be an http(s) URL")
+	}
+
+	if r.Cooldown < 0 {
+		return errors.New("cooldown must not be negative")
+	}
+
+	switch r.Type {
+	case AlertPriceCross:
+		if r.Level <= 0 {
+			return errors.New("level must be positive")
+		}
+		if r.Direction != "above" && r.Direction != "below" {
+			return errors.New(`direction must be "above" or "below"`)
+		}
+	case AlertPercentMove:
+		if r.Percent <= 0 {
+			return errors.New("percent must be positive")
+		}
+		if r.Window < time.Second || r.Window > 24*time.Hour {
+			return errors.New("window must be between 1s and 24h")
+		}
+	case AlertSpreadAbove:
+		if r.SpreadBps <= 0 {
+			return errors.New("spread_bps must be positive")
+		}
+	case AlertFeedStale:
+		if r.StaleAfter < time.Second {
+			return errors.New("stale_seconds must be at least 1")
+		}
+	default:
+		return fmt.Errorf("unknown alert type %q", r.Type)
+	}
+
+	return nil
+}
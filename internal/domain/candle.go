@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Candle is an OHLC bar for one exchange/symbol over [Start, Start+Interval).
+type Candle struct {
+	Exchange string
+	Symbol   string
+	Start    time.Time
+	Interval time.Duration
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Avg      float64
+	Count    int
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	MinPeriod = time.Second
+	MaxPeriod = 31 * 24 * time.Hour
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// Period is a lookback such as 5s, 3m, 2h, 1d or 1w. Unlike Postgres
+// intervals "m" always means minutes; days and weeks are calendar based.
+type Period struct {
+	N    int
+	Unit byte
+}
+
+func ParsePeriod(s string) (Period, error) {
+	if len(s) < 2 {
+		return Period{}, fmt.Errorf("%w: %q", ErrInvalidPeriod, s)
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return Period{}, fmt.Errorf("%w: %q", ErrInvalidPeriod, s)
+	}
+
+	p := Period{N: n, Unit: s[len(s)-1]}
+	switch p.Unit {
+	case 's', 'm', 'h', 'd', 'w':
+	default:
+		return Period{}, fmt.Errorf("%w: unknown unit in %q, use s, m, h, d or w", ErrInvalidPeriod, s)
+	}
+
+	if d := p.Duration(); d < MinPeriod || d > MaxPeriod {
+		return Period{}, fmt.Errorf("%w: %q is outside %s..%s", ErrInvalidPeriod, s, MinPeriod, MaxPeriod)
+	}
+
+	return p, nil
+}
+
+// Start returns the beginning of the period ending at now.
+func (p Period) Start(now time.Time) time.Time {
+	switch p.Unit {
+	case 'd':
+		return now.AddDate(0, 0, -p.N)
+	case 'w':
+		return now.AddDate(0, 0, -7*p.N)
+	default:
+		return now.Add(-p.Duration())
+	}
+}
+
+// Duration is the nominal length; days are counted as 24h.
+func (p Period) Duration() time.Duration {
+	n := time.Duration(p.N)
+	switch p.Unit {
+	case 's':
+		return n * time.Second
+	case 'm':
+		return n * time.Minute
+	case 'h':
+		return n * time.Hour
+	case 'd':
+		return n * 24 * time.Hour
+	case 'w':
+		return n * 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+func (p Period) String() string {
+	return strconv.Itoa(p.N) + string(p.Unit)
+}
+
+// TimeRange is a half-open [From, To) query range. A zero From means unbounded.
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// ParseTime accepts RFC 3339 timestamps or unix seconds.
+func ParseTime(value string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q, use RFC 3339 or unix seconds", value)
+	}
+	return t.UTC(), nil
+}
+
+// ParseRange resolves either a period or explicit from/to values into a
+// range ending no later than now. All three empty yields an unbounded range.
+func ParseRange(period, from, to string, now time.Time) (TimeRange, error) {
+	now = now.UTC()
+	r := TimeRange{To: now}
+
+	if period != "" && from != "" {
+		return TimeRange{}, errors.New("use either period or from/to, not both")
+	}
+
+	if to != "" {
+		t, err := ParseTime(to)
+		if err != nil {
+			return TimeRange{}, err
+		}
+		if t.After(now) {
+			t = now
+		}
+		r.To = t
+	}
+
+	switch {
+	case period != "":
+		p, err := ParsePeriod(period)
+		if err != nil {
+			return TimeRange{}, err
+		}
+		r.From = p.Start(r.To)
+	case from != "":
+		t, err := ParseTime(from)
+		if err != nil {
+			return TimeRange{}, err
+		}
+		if !t.Before(r.To) {
+			return TimeRange{}, errors.New("from must be before to")
+		}
+		if r.To.Sub(t) > MaxPeriod {
+			return TimeRange{}, fmt.Errorf("range longer than %s", MaxPeriod)
+		}
+		r.From = t
+	}
+
+	return r, nil
+}
@@ -6,6 +6,7 @@ type PriceUpdate struct {
 	Exchange   string
 	Symbol     string
 	Price      float64
+	Quantity   float64 // trade size, 0 when the feed does not send it
 	ReceivedAt time.Time
 	Type       string //"raw/min/max "
 	AvgPrice   float64
@@ -21,3 +22,26 @@ type AggregatedPrice struct {
 	MinPrice  float64
 	MaxPrice  float64
 }
+
+// ArbitrageOpportunity is a sustained cross-exchange spread: buying on
+// BuyExchange and selling on SellExchange nets NetSpreadBps after fees.
+type ArbitrageOpportunity struct {
+	ID              int64
+	Symbol          string
+	BuyExchange     string
+	SellExchange    string
+	BuyPrice        float64
+	SellPrice       float64
+	SpreadBps       float64
+	NetSpreadBps    float64
+	MaxNetSpreadBps float64
+	StartedAt       time.Time
+	DetectedAt      time.Time
+	EndedAt         *time.Time
+}
+
+// QuarantinedTick is a tick rejected by validation, kept for inspection.
+type QuarantinedTick struct {
+	Update PriceUpdate
+	Reason string
+}
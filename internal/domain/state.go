@@ -15,8 +15,16 @@ const (
 )
 
 type Manager struct {
-	current Mode
-	mu      sync.RWMutex
+	current   Mode
+	mu        sync.RWMutex
+	listeners []func(Mode)
+}
+
+// OnChange registers a callback invoked after every successful mode switch.
+func (m *Manager) OnChange(fn func(Mode)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
 }
 
 func NewModeManager() *Manager {
@@ -25,16 +33,22 @@ func NewModeManager() *Manager {
 	}
 }
 
+// SetMode switches the mode and then calls the OnChange listeners without
+// holding the lock, so they may call back into the manager.
 func (m *Manager) SetMode(ctx context.Context, mode Mode) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	if mode != ModeLive && mode != ModeTest {
 		return errors.New("invalid mode")
 	}
 
+	m.mu.Lock()
 	m.current = mode
+	listeners := append([]func(Mode){}, m.listeners...)
+	m.mu.Unlock()
+
 	slog.Info("Mode switched", "mode", mode.String())
+	for _, fn := range listeners {
+		fn(mode)
+	}
 	return nil
 }
 
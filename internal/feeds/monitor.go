@@ -0,0 +1,351 @@
+// Package feeds tracks the health of every (exchange, symbol) price feed so
+// a single symbol going quiet on one exchange is visible.
+package feeds
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusStale    = "stale"
+
+	// rateWindow is how far back tick rate and error ratio look.
+	rateWindow = 60
+)
+
+type Config struct {
+	// StaleAfter marks a feed stale when no tick arrived for this long.
+	StaleAfter time.Duration
+	// GapAfter counts a gap whenever two ticks are further apart than this.
+	GapAfter time.Duration
+	// MaxErrorRatio marks a feed degraded when decode errors and rejected
+	// ticks exceed this share of its messages over the last minute.
+	MaxErrorRatio float64
+}
+
+// Feed is a snapshot of one exchange/symbol feed.
+type Feed struct {
+	Exchange     string
+	Symbol       string
+	Status       string
+	Reasons      []string
+	LastTick     time.Time
+	Age          time.Duration
+	TickRate     float64 // ticks per second over the last minute
+	Ticks        int64
+	Rejected     int64
+	DecodeErrors int64
+	Gaps         int64
+	LastGap      time.Time
+}
+
+// Exchange is the connection side of a feed source.
+type Exchange struct {
+	Name         string
+	Connections  int
+	Reconnects   int64
+	DecodeErrors int64 // messages that could not be tied to a symbol
+	LastConnect  time.Time
+	LastError    string
+}
+
+// Summary counts feeds per status and lists the unhealthy ones.
+type Summary struct {
+	Total    int
+	OK       int
+	Degraded []string
+	Stale    []string
+}
+
+// buckets counts events per second for the last rateWindow seconds.
+type buckets struct {
+	counts [rateWindow]int
+	second int64
+}
+
+func (b *buckets) add(now time.Time) {
+	b.advance(now)
+	b.counts[b.second%rateWindow]++
+}
+
+func (b *buckets) advance(now time.Time) {
+	sec := now.Unix()
+	if b.second == 0 || sec-b.second >= rateWindow {
+		b.counts = [rateWindow]int{}
+	} else {
+		for s := b.second + 1; s <= sec; s++ {
+			b.counts[s%rateWindow] = 0
+		}
+	}
+	if sec > b.second {
+		b.second = sec
+	}
+}
+
+func (b *buckets) total(now time.Time) int {
+	b.advance(now)
+	n := 0
+	for _, c := range b.counts {
+		n += c
+	}
+	return n
+}
+
+type feedState struct {
+	lastTick     time.Time
+	ticks        int64
+	rejected     int64
+	decodeErrors int64
+	gaps         int64
+	lastGap      time.Time
+	recent       buckets
+	recentErrors buckets
+}
+
+// Monitor is safe for concurrent use.
+type Monitor struct {
+	cfg Config
+
+	mu        sync.Mutex
+	feeds     map[string]map[string]*feedState
+	exchanges map[string]*Exchange
+}
+
+func NewMonitor(cfg Config) *Monitor {
+	return &Monitor{
+		cfg:       cfg.withDefaults(),
+		feeds:     make(map[string]map[string]*feedState),
+		exchanges: make(map[string]*Exchange),
+	}
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = 30 * time.Second
+	}
+	if cfg.GapAfter <= 0 {
+		cfg.GapAfter = 10 * time.Second
+	}
+	if cfg.MaxErrorRatio <= 0 {
+		cfg.MaxErrorRatio = 0.05
+	}
+	return cfg
+}
+
+// SetConfig changes the thresholds; counters and history are kept.
+func (m *Monitor) SetConfig(cfg Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cfg = cfg.withDefaults()
+}
+
+// Expect registers a feed before its first tick, so a feed that never
+// starts shows up as stale instead of not at all.
+func (m *Monitor) Expect(exchange, symbol string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.feed(exchange, symbol)
+}
+
+// Forget drops a feed that is no longer expected. It comes back if the
+// exchange keeps sending the symbol.
+func (m *Monitor) Forget(exchange, symbol string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.feeds[exchange], symbol)
+}
+
+func (m *Monitor) feed(exchange, symbol string) *feedState {
+	bySymbol, ok := m.feeds[exchange]
+	if !ok {
+		bySymbol = make(map[string]*feedState)
+		m.feeds[exchange] = bySymbol
+	}
+	f, ok := bySymbol[symbol]
+	if !ok {
+		f = &feedState{}
+		bySymbol[symbol] = f
+	}
+	return f
+}
+
+func (m *Monitor) exchange(name string) *Exchange {
+	e, ok := m.exchanges[name]
+	if !ok {
+		e = &Exchange{Name: name}
+		m.exchanges[name] = e
+	}
+	return e
+}
+
+// Tick records a tick as it arrives from the exchange.
+func (m *Monitor) Tick(update domain.PriceUpdate) {
+	at := update.ReceivedAt
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f := m.feed(update.Exchange, update.Symbol)
+	if !f.lastTick.IsZero() && at.Sub(f.lastTick) > m.cfg.GapAfter {
+		f.gaps++
+		f.lastGap = at
+	}
+	if at.After(f.lastTick) {
+		f.lastTick = at
+	}
+	f.ticks++
+	f.recent.add(at)
+}
+
+// Rejected records a tick that failed validation. It is bucketed by
+// ReceivedAt like Tick, so the error ratio compares counts on one clock
+// even when validation lags behind the listeners.
+func (m *Monitor) Rejected(update domain.PriceUpdate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f := m.feed(update.Exchange, update.Symbol)
+	f.rejected++
+	f.recentErrors.add(update.ReceivedAt)
+}
+
+// DecodeError records a message that could not be parsed. symbol is empty
+// when the message was too broken to tell. It is called as the line is
+// read, the moment ReceivedAt would have recorded.
+func (m *Monitor) DecodeError(exchange, symbol string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if symbol == "" {
+		m.exchange(exchange).DecodeErrors++
+		return
+	}
+	f := m.feed(exchange, symbol)
+	f.decodeErrors++
+	f.recentErrors.add(time.Now())
+}
+
+// Connected records a new connection to an exchange.
+func (m *Monitor) Connected(exchange string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.exchange(exchange)
+	if !e.LastConnect.IsZero() {
+		e.Reconnects++
+	}
+	e.Connections++
+	e.LastConnect = time.Now()
+}
+
+// Disconnected records a closed connection and why, if known.
+func (m *Monitor) Disconnected(exchange string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.exchange(exchange)
+	if e.Connections > 0 {
+		e.Connections--
+	}
+	if err != nil {
+		e.LastError = err.Error()
+	}
+}
+
+// ConnectFailed records a failed dial.
+func (m *Monitor) ConnectFailed(exchange string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exchange(exchange).LastError = err.Error()
+}
+
+// Feeds returns all feeds sorted by exchange and symbol.
+func (m *Monitor) Feeds(now time.Time) []Feed {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []Feed
+	for exchange, bySymbol := range m.feeds {
+		for symbol, f := range bySymbol {
+			result = append(result, m.snapshot(exchange, symbol, f, now))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+func (m *Monitor) snapshot(exchange, symbol string, f *feedState, now time.Time) Feed {
+	ticks := f.recent.total(now)
+	errs := f.recentErrors.total(now)
+
+	feed := Feed{
+		Exchange:     exchange,
+		Symbol:       symbol,
+		Status:       StatusOK,
+		LastTick:     f.lastTick,
+		TickRate:     float64(ticks) / rateWindow,
+		Ticks:        f.ticks,
+		Rejected:     f.rejected,
+		DecodeErrors: f.decodeErrors,
+		Gaps:         f.gaps,
+		LastGap:      f.lastGap,
+	}
+	if !f.lastTick.IsZero() {
+		feed.Age = now.Sub(f.lastTick)
+	}
+
+	if f.lastTick.IsZero() || feed.Age > m.cfg.StaleAfter {
+		feed.Status = StatusStale
+		feed.Reasons = append(feed.Reasons, "no recent ticks")
+		return feed
+	}
+	if total := ticks + errs; total > 0 && float64(errs)/float64(total) > m.cfg.MaxErrorRatio {
+		feed.Status = StatusDegraded
+		feed.Reasons = append(feed.Reasons, "high error ratio")
+	}
+	if !f.lastGap.IsZero() && now.Sub(f.lastGap) < rateWindow*time.Second {
+		feed.Status = StatusDegraded
+		feed.Reasons = append(feed.Reasons, "recent gap")
+	}
+	return feed
+}
+
+// Exchanges returns the connection state of every exchange seen so far.
+func (m *Monitor) Exchanges() []Exchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]Exchange, 0, len(m.exchanges))
+	for _, e := range m.exchanges {
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Summarize counts feeds by status.
+func Summarize(feeds []Feed) Summary {
+	s := Summary{Total: len(feeds)}
+	for _, f := range feeds {
+		key := f.Exchange + ":" + f.Symbol
+		switch f.Status {
+		case StatusStale:
+			s.Stale = append(s.Stale, key)
+		case StatusDegraded:
+			s.Degraded = append(s.Degraded, key)
+		default:
+			s.OK++
+		}
+	}
+	return s
+}
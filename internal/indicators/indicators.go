@@ -0,0 +1,230 @@
+// Package indicators computes technical indicators over bar closes. Every
+// indicator is incremental: Update takes the next close, so the same code
+// serves both historical series and live bars.
+package indicators
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	TypeSMA       = "sma"
+	TypeEMA       = "ema"
+	TypeRSI       = "rsi"
+	TypeBollinger = "bollinger"
+
+	MaxLength = 500
+
+	// DefaultBollingerMult is the band width in standard deviations.
+	DefaultBollingerMult = 2.0
+)
+
+var ErrUnknownType = errors.New("unknown indicator type")
+
+// Value is one indicator output. Upper and Lower are only set by Bollinger
+// bands, where Value is the middle band.
+type Value struct {
+	Value float64
+	Upper float64
+	Lower float64
+}
+
+// Indicator consumes closes in order. ok is false while the indicator is
+// still warming up.
+type Indicator interface {
+	Update(close float64) (v Value, ok bool)
+	// Warmup is how many bars to feed before the output is worth showing.
+	Warmup() int
+}
+
+// New builds an indicator of the given type.
+func New(kind string, length int) (Indicator, error) {
+	if length < 2 || length > MaxLength {
+		return nil, fmt.Errorf("length must be between 2 and %d", MaxLength)
+	}
+	switch kind {
+	case TypeSMA:
+		return NewSMA(length), nil
+	case TypeEMA:
+		return NewEMA(length), nil
+	case TypeRSI:
+		return NewRSI(length), nil
+	case TypeBollinger:
+		return NewBollinger(length, DefaultBollingerMult), nil
+	}
+	return nil, fmt.Errorf("%w %q, use sma, ema, rsi or bollinger", ErrUnknownType, kind)
+}
+
+// Compute runs ind over closes. The result is aligned with closes and holds
+// nil while the indicator warms up.
+func Compute(ind Indicator, closes []float64) []*Value {
+	out := make([]*Value, len(closes))
+	for i, c := range closes {
+		if v, ok := ind.Update(c); ok {
+			out[i] = &v
+		}
+	}
+	return out
+}
+
+// window keeps the last n closes with a running sum.
+type window struct {
+	values []float64
+	next   int
+	full   bool
+	sum    float64
+}
+
+func newWindow(n int) *window {
+	return &window{values: make([]float64, n)}
+}
+
+func (w *window) push(v float64) {
+	w.sum += v - w.values[w.next]
+	w.values[w.next] = v
+	w.next = (w.next + 1) % len(w.values)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+func (w *window) mean() float64 {
+	return w.sum / float64(len(w.values))
+}
+
+// stdDev walks the window instead of keeping a sum of squares, which loses
+// all precision at BTC-sized prices.
+func (w *window) stdDev() float64 {
+	m := w.mean()
+	var sq float64
+	for _, v := range w.values {
+		sq += (v - m) * (v - m)
+	}
+	return math.Sqrt(sq / float64(len(w.values)))
+}
+
+// SMA is the simple moving average of the last Length closes.
+type SMA struct {
+	w *window
+}
+
+func NewSMA(length int) *SMA {
+	return &SMA{w: newWindow(length)}
+}
+
+func (s *SMA) Update(close float64) (Value, bool) {
+	s.w.push(close)
+	if !s.w.full {
+		return Value{}, false
+	}
+	return Value{Value: s.w.mean()}, true
+}
+
+func (s *SMA) Warmup() int { return len(s.w.values) }
+
+// EMA is the exponential moving average, seeded with the SMA of the first
+// Length closes.
+type EMA struct {
+	length int
+	alpha  float64
+	seen   int
+	sum    float64
+	value  float64
+}
+
+func NewEMA(length int) *EMA {
+	return &EMA{length: length, alpha: 2 / float64(length+1)}
+}
+
+func (e *EMA) Update(close float64) (Value, bool) {
+	e.seen++
+	if e.seen < e.length {
+		e.sum += close
+		return Value{}, false
+	}
+	if e.seen == e.length {
+		e.value = (e.sum + close) / float64(e.length)
+	} else {
+		e.value += e.alpha * (close - e.value)
+	}
+	return Value{Value: e.value}, true
+}
+
+// The seed's influence fades slowly, so give it a few lengths of history.
+func (e *EMA) Warmup() int { return 3 * e.length }
+
+// RSI is Wilder's relative strength index on a 0..100 scale.
+type RSI struct {
+	length  int
+	seen    int
+	prev    float64
+	avgGain float64
+	avgLoss float64
+}
+
+func NewRSI(length int) *RSI {
+	return &RSI{length: length}
+}
+
+func (r *RSI) Update(close float64) (Value, bool) {
+	r.seen++
+	if r.seen == 1 {
+		r.prev = close
+		return Value{}, false
+	}
+
+	change := close - r.prev
+	r.prev = close
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+	n := float64(r.length)
+	changes := r.seen - 1
+	switch {
+	case changes < r.length:
+		r.avgGain += gain
+		r.avgLoss += loss
+		return Value{}, false
+	case changes == r.length:
+		r.avgGain = (r.avgGain + gain) / n
+		r.avgLoss = (r.avgLoss + loss) / n
+	default:
+		r.avgGain = (r.avgGain*(n-1) + gain) / n
+		r.avgLoss = (r.avgLoss*(n-1) + loss) / n
+	}
+
+	if r.avgLoss == 0 {
+		if r.avgGain == 0 {
+			return Value{Value: 50}, true
+		}
+		return Value{Value: 100}, true
+	}
+	rs := r.avgGain / r.avgLoss
+	return Value{Value: 100 - 100/(1+rs)}, true
+}
+
+func (r *RSI) Warmup() int { return 3 * r.length }
+
+// Bollinger bands are the SMA plus and minus Mult population standard
+// deviations of the same window.
+type Bollinger struct {
+	w    *window
+	mult float64
+}
+
+func NewBollinger(length int, mult float64) *Bollinger {
+	return &Bollinger{w: newWindow(length), mult: mult}
+}
+
+func (b *Bollinger) Update(close float64) (Value, bool) {
+	b.w.push(close)
+	if !b.w.full {
+		return Value{}, false
+	}
+	mid := b.w.mean()
+	band := b.mult * b.w.stdDev()
+	return Value{Value: mid, Upper: mid + band, Lower: mid - band}, true
+}
+
+func (b *Bollinger) Warmup() int { return len(b.w.values) }
@@ -0,0 +1,144 @@
+package indicators
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func near(a, b, tol float64) bool { return math.Abs(a-b) <= tol }
+
+// feed runs closes through ind and returns the outputs once it is ready.
+func feed(t *testing.T, ind Indicator, closes []float64) []Value {
+	t.Helper()
+	var out []Value
+	for _, v := range Compute(ind, closes) {
+		if v != nil {
+			out = append(out, *v)
+		}
+	}
+	return out
+}
+
+func TestSMA(t *testing.T) {
+	got := feed(t, NewSMA(3), []float64{1, 2, 3, 4, 5, 9})
+	want := []float64{2, 3, 4, 6}
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !near(got[i].Value, want[i], 1e-12) {
+			t.Errorf("SMA[%d] = %g, want %g", i, got[i].Value, want[i])
+		}
+	}
+}
+
+func TestEMASeedsWithSMA(t *testing.T) {
+	e := NewEMA(3)
+	for i, c := range []float64{1, 2} {
+		if _, ok := e.Update(c); ok {
+			t.Fatalf("EMA ready after %d closes, want %d", i+1, 3)
+		}
+	}
+
+	// alpha = 2/(3+1) = 0.5
+	tests := []struct {
+		close, want float64
+	}{
+		{3, 2},   // seed: SMA of 1, 2, 3
+		{4, 3},   // 2 + 0.5*(4-2)
+		{0, 1.5}, // 3 + 0.5*(0-3)
+		{1.5, 1.5},
+	}
+	for _, tt := range tests {
+		v, ok := e.Update(tt.close)
+		if !ok || !near(v.Value, tt.want, 1e-12) {
+			t.Errorf("Update(%g) = %g, %v, want %g", tt.close, v.Value, ok, tt.want)
+		}
+	}
+}
+
+// The 14-period example StockCharts uses for Wilder's RSI. Their table
+// rounds the intermediate averages and lands up to 0.07 higher; these are
+// the values computed without rounding.
+func TestRSIWilder(t *testing.T) {
+	closes := []float64{
+		44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+		45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+		46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57,
+		43.42, 42.66, 43.13,
+	}
+	want := []float64{
+		70.46, 66.25, 66.48, 69.35, 66.29, 57.92, 62.88, 63.21, 56.01, 62.34,
+		54.67, 50.39, 40.02, 41.49, 41.90, 45.50, 37.32, 33.09, 37.79,
+	}
+
+	got := feed(t, NewRSI(14), closes)
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d (first after 14 changes)", len(got), len(want))
+	}
+	for i := range want {
+		if !near(got[i].Value, want[i], 0.01) {
+			t.Errorf("RSI[%d] = %.2f, want %.2f", i, got[i].Value, want[i])
+		}
+	}
+}
+
+func TestRSIFlatAndOneSided(t *testing.T) {
+	tests := []struct {
+		name   string
+		closes []float64
+		want   float64
+	}{
+		{"flat", []float64{5, 5, 5, 5}, 50},
+		{"only gains", []float64{1, 2, 3, 4}, 100},
+		{"only losses", []float64{4, 3, 2, 1}, 0},
+	}
+	for _, tt := range tests {
+		got := feed(t, NewRSI(3), tt.closes)
+		if len(got) != 1 || got[0].Value != tt.want {
+			t.Errorf("%s: got %+v, want %g", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	t.Run("constant input", func(t *testing.T) {
+		got := feed(t, NewBollinger(5, 2), []float64{67000, 67000, 67000, 67000, 67000, 67000})
+		if len(got) != 2 {
+			t.Fatalf("got %d values, want 2", len(got))
+		}
+		for _, v := range got {
+			if v.Value != 67000 || v.Upper != 67000 || v.Lower != 67000 {
+				t.Errorf("got %+v, want all bands at 67000", v)
+			}
+		}
+	})
+
+	t.Run("population deviation", func(t *testing.T) {
+		// Mean 5, population standard deviation 2
+		got := feed(t, NewBollinger(8, 2), []float64{2, 4, 4, 4, 5, 5, 7, 9})
+		if len(got) != 1 {
+			t.Fatalf("got %d values, want 1", len(got))
+		}
+		if v := got[0]; !near(v.Value, 5, 1e-12) || !near(v.Upper, 9, 1e-12) || !near(v.Lower, 1, 1e-12) {
+			t.Errorf("got %+v, want 5 with bands 1..9", v)
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	for _, kind := range []string{TypeSMA, TypeEMA, TypeRSI, TypeBollinger} {
+		if _, err := New(kind, 14); err != nil {
+			t.Errorf("New(%q, 14): %v", kind, err)
+		}
+	}
+	if _, err := New("macd", 14); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("New(macd) = %v, want ErrUnknownType", err)
+	}
+	for _, length := range []int{1, MaxLength + 1} {
+		if _, err := New(TypeSMA, length); err == nil {
+			t.Errorf("New(sma, %d) accepted an out of range length", length)
+		}
+	}
+}
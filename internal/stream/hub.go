@@ -0,0 +1,138 @@
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a single message fanned out to subscribers.
+type Event struct {
+	ID       uint64
+	Type     string
+	Exchange string
+	Symbol   string
+	Time     time.Time
+	Payload  interface{}
+}
+
+// Subscriber receives events matching its filter on C. C is closed when the
+// subscriber is evicted for falling behind or unsubscribes.
+type Subscriber struct {
+	C       <-chan Event
+	ch      chan Event
+	filter  func(Event) bool
+	evicted bool
+	once    sync.Once
+}
+
+// Evicted reports whether the hub dropped the subscriber for being too slow.
+func (s *Subscriber) Evicted() bool {
+	return s.evicted
+}
+
+func (s *Subscriber) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// Hub is an in-process broadcaster with a small replay history so clients
+// can resume from the last event they saw.
+type Hub struct {
+	mu      sync.Mutex
+	nextID  uint64
+	subs    map[*Subscriber]struct{}
+	history []Event
+	histLen int
+	histPos int
+}
+
+func NewHub(historySize int) *Hub {
+	if historySize <= 0 {
+		historySize = 1024
+	}
+	return &Hub{
+		subs:    make(map[*Subscriber]struct{}),
+		history: make([]Event, historySize),
+	}
+}
+
+// Publish stamps the event with the next ID and delivers it without blocking.
+// Subscribers whose buffer is full are evicted.
+func (h *Hub) Publish(eventType, exchange, symbol string, payload interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	ev := Event{
+		ID:       h.nextID,
+		Type:     eventType,
+		Exchange: exchange,
+		Symbol:   symbol,
+		Time:     time.Now().UTC(),
+		Payload:  payload,
+	}
+
+	h.history[h.histPos] = ev
+	h.histPos = (h.histPos + 1) % len(h.history)
+	if h.histLen < len(h.history) {
+		h.histLen++
+	}
+
+	for sub := range h.subs {
+		if sub.filter != nil && !sub.filter(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.evicted = true
+			delete(h.subs, sub)
+			sub.close()
+		}
+	}
+}
+
+// Subscribe registers a subscriber with the given buffer size. Events newer
+// than lastID still in the history are replayed first; pass 0 to skip replay.
+func (h *Hub) Subscribe(lastID uint64, buffer int, filter func(Event) bool) *Subscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Event
+	if lastID > 0 {
+		start := (h.histPos - h.histLen + len(h.history)) % len(h.history)
+		for i := 0; i < h.histLen; i++ {
+			ev := h.history[(start+i)%len(h.history)]
+			if ev.ID > lastID && (filter == nil || filter(ev)) {
+				replay = append(replay, ev)
+			}
+		}
+	}
+
+	if buffer < len(replay) {
+		buffer = len(replay)
+	}
+
+	ch := make(chan Event, buffer)
+	for _, ev := range replay {
+		ch <- ev
+	}
+
+	sub := &Subscriber{C: ch, ch: ch, filter: filter}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subs, sub)
+	sub.close()
+}
+
+// Subscribers returns the number of connected subscribers.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/stream"
+)
+
+// candleBuilder turns the tick stream into one-minute candles and publishes
+// each candle on the hub once its minute has passed.
+type candleBuilder struct {
+	mu      sync.Mutex
+	hub     *stream.Hub
+	current map[string]*candleState
+}
+
+type candleState struct {
+	candle domain.Candle
+	sum    float64
+}
+
+func newCandleBuilder(hub *stream.Hub) *candleBuilder {
+	return &candleBuilder{
+		hub:     hub,
+		current: make(map[string]*candleState),
+	}
+}
+
+func (cb *candleBuilder) add(update domain.PriceUpdate) {
+	start := update.ReceivedAt.UTC().Truncate(time.Minute)
+	key := update.Exchange + ":" + update.Symbol
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state, ok := cb.current[key]
+	if ok && state.candle.Start.Before(start) {
+		cb.publish(state)
+		ok = false
+	}
+	if !ok {
+		state = &candleState{candle: domain.Candle{
+			Exchange: update.Exchange,
+			Symbol:   update.Symbol,
+			Start:    start,
+			Interval: time.Minute,
+			Open:     update.Price,
+			High:     update.Price,
+			Low:      update.Price,
+		}}
+		cb.current[key] = state
+	}
+
+	c := &state.candle
+	if update.Price > c.High {
+		c.High = update.Price
+	}
+	if update.Price < c.Low {
+		c.Low = update.Price
+	}
+	c.Close = update.Price
+	c.Count++
+	state.sum += update.Price
+}
+
+// run closes candles whose minute is over even when no new tick arrives.
+func (cb *candleBuilder) run() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		current := now.UTC().Truncate(time.Minute)
+
+		cb.mu.Lock()
+		for key, state := range cb.current {
+			if state.candle.Start.Before(current) {
+				cb.publish(state)
+				delete(cb.current, key)
+			}
+		}
+		cb.mu.Unlock()
+	}
+}
+
+func (cb *candleBuilder) publish(state *candleState) {
+	c := state.candle
+	c.Avg = state.sum / float64(c.Count)
+	cb.hub.Publish("candle", c.Exchange, c.Symbol, c)
+}
@@ -3,61 +3,215 @@ package worker
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"marketflow/internal/adapters/cache"
 	"marketflow/internal/adapters/exchange"
 	"marketflow/internal/adapters/storage"
 	"marketflow/internal/domain"
+	"marketflow/internal/feeds"
+	"marketflow/internal/stream"
 )
 
-func StartIngestion(logger *slog.Logger, redisClient *cache.RedisClient, db *sql.DB, toPG chan domain.PriceUpdate, modeManager *domain.Manager) {
-	fanIn := make(chan domain.PriceUpdate, 10000)
-	toRedis := make(chan domain.PriceUpdate, 10000)
-
-	// Start mode manager
-	go func() {
-		for {
-			mode := modeManager.GetMode()
-			logger.Info("Current mode", "mode", mode)
-
-			switch mode {
-			case domain.ModeLive:
-				logger.Info("Starting live mode listeners")
-				go exchange.ListenToExchange("exchange1:40101", "binance", fanIn, logger)
-				go exchange.ListenToExchange("exchange2:40102", "coinbase", fanIn, logger)
-				go exchange.ListenToExchange("exchange3:40103", "kucoin", fanIn, logger)
-			case domain.ModeTest:
-				logger.Info("Starting test mode generators")
-				ctx := context.Background()
-				go exchange.StartTestGenerators(ctx, fanIn)
-			}
+// IngestionConfig sizes the channels and worker pools of the pipeline.
+type IngestionConfig struct {
+	Sources           []exchange.Source
+	FanInBuffer       int
+	RedisBuffer       int
+	QuarantineBuffer  int
+	RedisWorkers      int
+	PoolWorkers       int
+	AggregateEvery    int
+	AggregateInterval time.Duration
+	RedisTimeout      time.Duration
+	BatchSize         int
+	FlushInterval     time.Duration
+	ReconnectDelay    time.Duration
+}
 
-			time.Sleep(10 * time.Second) // Check mode less frequently
-		}
-	}()
+// Ingestion is a running pipeline. Reconfigure applies the reloadable
+// settings to it without draining or recreating any channel.
+type Ingestion struct {
+	mode       *domain.Manager
+	fanIn      chan domain.PriceUpdate
+	listeners  *exchange.Supervisor
+	redis      *workerGroup
+	validation atomic.Pointer[ValidationConfig]
+	newPool    func(exchangeName string) *workerPool
 
-	// Start Redis workers
-	for i := 0; i < 20; i++ { // Increased number of workers
-		go redisWorker(i, toRedis, redisClient, logger)
+	mu             sync.Mutex
+	cfg            IngestionConfig
+	pools          map[string]*workerPool
+	stopGenerators context.CancelFunc // nil unless in test mode
+	generating     []string           // exchanges the generators run for
+}
+
+func StartIngestion(logger *slog.Logger, redisClient *cache.RedisClient, db *sql.DB, toPG chan domain.PriceUpdate, modeManager *domain.Manager, hub *stream.Hub, stats *StatsEngine, monitor *feeds.Monitor, pipeline *Pipeline, cfg IngestionConfig, validation ValidationConfig) *Ingestion {
+	fanIn := make(chan domain.PriceUpdate, cfg.FanInBuffer)
+	validated := make(chan domain.PriceUpdate, cfg.FanInBuffer)
+	quarantine := make(chan domain.QuarantinedTick, cfg.QuarantineBuffer)
+	toRedis := make(chan domain.PriceUpdate, cfg.RedisBuffer)
+
+	pipeline.Track("fan_in", func() int { return len(fanIn) }, cap(fanIn))
+	pipeline.Track("validated", func() int { return len(validated) }, cap(validated))
+	pipeline.Track("quarantine", func() int { return len(quarantine) }, cap(quarantine))
+	pipeline.Track("to_redis", func() int { return len(toRedis) }, cap(toRedis))
+
+	candles := newCandleBuilder(hub)
+	go candles.run()
+
+	ing := &Ingestion{
+		mode:      modeManager,
+		fanIn:     fanIn,
+		listeners: exchange.NewSupervisor(fanIn, monitor, logger),
+		redis: newWorkerGroup(func(workerID int, quit <-chan struct{}) {
+			redisWorker(workerID, quit, toRedis, redisClient, cfg.RedisTimeout, logger)
+		}),
+		cfg:   cfg,
+		pools: make(map[string]*workerPool),
 	}
+	ing.validation.Store(&validation)
+	ing.newPool = func(exchangeName string) *workerPool {
+		in := make(chan domain.PriceUpdate, ing.cfg.FanInBuffer)
+		pipeline.Track("pool:"+exchangeName, func() int { return len(in) }, cap(in))
+		return startWorkerPool(in, toRedis, toPG, hub, candles, stats, ing.cfg, redisClient, logger)
+	}
+
+	// Live listeners and test generators follow the mode
+	ing.syncMode()
+	modeManager.OnChange(func(domain.Mode) { ing.syncMode() })
+
+	// Drop bad ticks before they reach Redis, PostgreSQL or the aggregates
+	go runValidation(&ing.validation, modeManager, monitor, fanIn, validated, quarantine, logger)
+	go storage.SaveQuarantine(quarantine, db, logger)
+
+	// Start Redis workers
+	ing.redis.resize(cfg.RedisWorkers)
 
 	// Start processing workers
-	startWorkerPool("binance", fanIn, toRedis, toPG, redisClient, logger)
-	startWorkerPool("coinbase", fanIn, toRedis, toPG, redisClient, logger)
-	startWorkerPool("kucoin", fanIn, toRedis, toPG, redisClient, logger)
+	for _, src := range cfg.Sources {
+		ing.pool(src.Name)
+	}
+	go ing.dispatch(validated)
 
 	// Start PostgreSQL saver
-	go storage.SaveBatchToPostgres(toPG, db, logger)
+	go storage.SaveBatchToPostgres(toPG, db, cfg.BatchSize, cfg.FlushInterval, logger)
+
+	return ing
+}
+
+// pool returns the worker pool of an exchange, starting it on first use.
+func (ing *Ingestion) pool(exchangeName string) *workerPool {
+	ing.mu.Lock()
+	defer ing.mu.Unlock()
+
+	p, ok := ing.pools[exchangeName]
+	if !ok {
+		p = ing.newPool(exchangeName)
+		ing.pools[exchangeName] = p
+	}
+	return p
+}
+
+// dispatch hands every validated tick to its exchange's pool. Sends block
+// rather than drop, so a slow pool backs up into validation and fan-in.
+func (ing *Ingestion) dispatch(validated <-chan domain.PriceUpdate) {
+	for update := range validated {
+		ing.pool(update.Exchange).in <- update
+	}
 }
 
-func redisWorker(workerID int, toRedis <-chan domain.PriceUpdate, redisClient *cache.RedisClient, logger *slog.Logger) {
-	for update := range toRedis {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+// syncMode runs either the exchange listeners or the test generators, never
+// both, so synthetic ticks do not mix with live ones.
+func (ing *Ingestion) syncMode() {
+	ing.mu.Lock()
+	defer ing.mu.Unlock()
+	ing.syncModeLocked()
+}
+
+func (ing *Ingestion) syncModeLocked() {
+	// Read under ing.mu so overlapping switches settle on the latest mode
+	switch ing.mode.GetMode() {
+	case domain.ModeLive:
+		if ing.stopGenerators != nil {
+			ing.stopGenerators()
+			ing.stopGenerators = nil
+		}
+		ing.listeners.Apply(ing.cfg.Sources, ing.cfg.ReconnectDelay)
+	case domain.ModeTest:
+		ing.listeners.Apply(nil, 0)
+		names := make([]string, len(ing.cfg.Sources))
+		for i, src := range ing.cfg.Sources {
+			names[i] = src.Name
+		}
+		if ing.stopGenerators != nil && slices.Equal(names, ing.generating) {
+			return
+		}
+		if ing.stopGenerators != nil {
+			ing.stopGenerators()
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		ing.stopGenerators, ing.generating = cancel, names
+		exchange.StartTestGenerators(ctx, names, ing.fanIn)
+	}
+}
+
+// Reconfigure applies a reloaded config. Sources, worker counts,
+// AggregateEvery and the validation rules take effect; buffer sizes, batch
+// settings and timeouts keep their startup values. Listeners for unchanged
+// sources stay connected and resized pools finish the tick in hand.
+func (ing *Ingestion) Reconfigure(cfg IngestionConfig, validation ValidationConfig) {
+	ing.validation.Store(&validation)
+
+	ing.mu.Lock()
+	ing.cfg.Sources = cfg.Sources
+	ing.cfg.RedisWorkers = cfg.RedisWorkers
+	ing.cfg.PoolWorkers = cfg.PoolWorkers
+	ing.cfg.AggregateEvery = cfg.AggregateEvery
+
+	ing.redis.resize(cfg.RedisWorkers)
+
+	for _, src := range cfg.Sources {
+		if _, ok := ing.pools[src.Name]; !ok {
+			ing.pools[src.Name] = ing.newPool(src.Name)
+		}
+	}
+	// Pools of removed sources keep running for the ticks still on their way
+	for _, pool := range ing.pools {
+		pool.workers.resize(cfg.PoolWorkers)
+		pool.aggregateEvery.Store(int64(cfg.AggregateEvery))
+	}
+
+	ing.syncModeLocked()
+	ing.mu.Unlock()
+}
+
+func redisWorker(workerID int, quit <-chan struct{}, toRedis <-chan domain.PriceUpdate, redisClient *cache.RedisClient, timeout time.Duration, logger *slog.Logger) {
+	for {
+		var update domain.PriceUpdate
+		select {
+		case <-quit:
+			return
+		case u, ok := <-toRedis:
+			if !ok {
+				return
+			}
+			update = u
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		err := redisClient.AddPrice(ctx, update.Exchange, update.Symbol, update.Price)
 		cancel()
 
+		if errors.Is(err, cache.ErrCircuitOpen) {
+			// Redis is down, PostgreSQL still gets the update
+			continue
+		}
 		if err != nil {
 			logger.Error("Failed to save price to Redis",
 				"worker", workerID,
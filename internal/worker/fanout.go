@@ -3,20 +3,38 @@ package worker
 import (
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"marketflow/internal/adapters/cache"
 	"marketflow/internal/domain"
+	"marketflow/internal/stream"
+	"marketflow/pkg/metrics"
 )
 
+var (
+	processedTotal = metrics.NewCounterVec("marketflow_worker_processed_total", "Ticks handled by the worker pools.", "exchange")
+	droppedTotal   = metrics.NewCounterVec("marketflow_worker_dropped_total", "Updates dropped because a downstream channel was full.", "exchange", "queue")
+)
+
+// workerPool processes the ticks of one exchange, fed by Ingestion.dispatch.
+type workerPool struct {
+	in             chan domain.PriceUpdate
+	workers        *workerGroup
+	aggregateEvery atomic.Int64
+}
+
 func startWorkerPool(
-	exchangeName string,
-	in <-chan domain.PriceUpdate,
+	in chan domain.PriceUpdate,
 	toRedis chan<- domain.PriceUpdate,
 	toPG chan<- domain.PriceUpdate,
+	hub *stream.Hub,
+	candles *candleBuilder,
+	statsEngine *StatsEngine,
+	cfg IngestionConfig,
 	redisClient *cache.RedisClient,
 	logger *slog.Logger,
-) {
+) *workerPool {
 	type symbolStats struct {
 		prices     []float64
 		lastUpdate domain.PriceUpdate
@@ -26,57 +44,72 @@ func startWorkerPool(
 	stats := make(map[string]*symbolStats)
 	var statsMu sync.Mutex
 
-	// Увеличиваем количество воркеров до 10
-	for i := 0; i < 10; i++ {
-		go func(workerID int) {
-			for update := range in {
-				if update.Exchange != exchangeName {
-					continue
-				}
+	pool := &workerPool{in: in}
+	pool.aggregateEvery.Store(int64(cfg.AggregateEvery))
 
-				// Неблокирующая отправка в Redis
-				select {
-				case toRedis <- update:
-				default:
-					logger.Warn("Redis channel full, dropping update",
-						"worker", workerID,
-						"symbol", update.Symbol)
+	pool.workers = newWorkerGroup(func(workerID int, quit <-chan struct{}) {
+		for {
+			var update domain.PriceUpdate
+			select {
+			case <-quit:
+				return
+			case u, ok := <-in:
+				if !ok {
+					return
 				}
+				update = u
+			}
 
-				// Неблокирующая отправка в PostgreSQL
-				select {
-				case toPG <- update:
-				default:
-					logger.Warn("PG channel full, dropping update",
-						"worker", workerID,
-						"symbol", update.Symbol)
-				}
+			// Неблокирующая отправка в Redis
+			select {
+			case toRedis <- update:
+			default:
+				droppedTotal.With(update.Exchange, "redis").Inc()
+				logger.Warn("Redis channel full, dropping update",
+					"worker", workerID,
+					"symbol", update.Symbol)
+			}
 
-				// Агрегация данных
-				statsMu.Lock()
-				stat, ok := stats[update.Symbol]
-				if !ok {
-					stat = &symbolStats{}
-					stats[update.Symbol] = stat
-				}
-				stat.mu.Lock()
-				stat.prices = append(stat.prices, update.Price)
-				stat.lastUpdate = update
+			// Неблокирующая отправка в PostgreSQL
+			select {
+			case toPG <- update:
+			default:
+				droppedTotal.With(update.Exchange, "postgres").Inc()
+				logger.Warn("PG channel full, dropping update",
+					"worker", workerID,
+					"symbol", update.Symbol)
+			}
 
-				// Агрегируем каждые 5 сообщений
-				if len(stat.prices) >= 5 {
-					aggregateAndSend(stat.prices, stat.lastUpdate, toPG, logger, workerID)
-					stat.prices = nil
-				}
-				stat.mu.Unlock()
-				statsMu.Unlock()
+			hub.Publish("tick", update.Exchange, update.Symbol, update)
+			candles.add(update)
+			statsEngine.Add(update)
+			processedTotal.With(update.Exchange).Inc()
+
+			// Агрегация данных
+			statsMu.Lock()
+			stat, ok := stats[update.Symbol]
+			if !ok {
+				stat = &symbolStats{}
+				stats[update.Symbol] = stat
 			}
-		}(i)
-	}
+			stat.mu.Lock()
+			stat.prices = append(stat.prices, update.Price)
+			stat.lastUpdate = update
+
+			// Агрегируем каждые AggregateEvery сообщений
+			if len(stat.prices) >= int(pool.aggregateEvery.Load()) {
+				aggregateAndSend(stat.prices, stat.lastUpdate, toPG, logger, workerID)
+				stat.prices = nil
+			}
+			stat.mu.Unlock()
+			statsMu.Unlock()
+		}
+	})
+	pool.workers.resize(cfg.PoolWorkers)
 
 	// Фоновая горутина для агрегации оставшихся данных
 	go func() {
-		ticker := time.NewTicker(500 * time.Millisecond) // Уменьшаем интервал
+		ticker := time.NewTicker(cfg.AggregateInterval)
 		defer ticker.Stop()
 
 		for range ticker.C {
@@ -92,6 +125,8 @@ func startWorkerPool(
 			statsMu.Unlock()
 		}
 	}()
+
+	return pool
 }
 
 func aggregateAndSend(prices []float64, update domain.PriceUpdate, toPG chan<- domain.PriceUpdate, logger *slog.Logger, workerID int) {
@@ -132,6 +167,7 @@ func aggregateAndSend(prices []float64, update domain.PriceUpdate, toPG chan<- d
 	select {
 	case toPG <- agg:
 	default:
+		droppedTotal.With(update.Exchange, "aggregate").Inc()
 		logger.Warn("PG channel full, dropping aggregate")
 	}
 }
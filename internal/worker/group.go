@@ -0,0 +1,34 @@
+package worker
+
+import "sync"
+
+// workerGroup runs a resizable number of identical workers. A worker is
+// only told to stop between items, so shrinking never loses one in flight.
+type workerGroup struct {
+	run func(workerID int, quit <-chan struct{})
+
+	mu     sync.Mutex
+	quits  []chan struct{}
+	nextID int
+}
+
+func newWorkerGroup(run func(workerID int, quit <-chan struct{})) *workerGroup {
+	return &workerGroup{run: run}
+}
+
+func (g *workerGroup) resize(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for len(g.quits) < n {
+		quit := make(chan struct{})
+		g.quits = append(g.quits, quit)
+		go g.run(g.nextID, quit)
+		g.nextID++
+	}
+	for len(g.quits) > n {
+		last := len(g.quits) - 1
+		close(g.quits[last])
+		g.quits = g.quits[:last]
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"sort"
+	"sync"
+)
+
+// Queue is a snapshot of one pipeline channel.
+type Queue struct {
+	Name string
+	Len  int
+	Cap  int
+}
+
+type queueProbe struct {
+	name     string
+	length   func() int
+	capacity int
+}
+
+// Pipeline exposes the fill level of the ingestion channels so health
+// checks can spot a backed-up stage.
+type Pipeline struct {
+	mu     sync.Mutex
+	queues []queueProbe
+}
+
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// Track registers a channel by name through its len and cap.
+func (p *Pipeline) Track(name string, length func() int, capacity int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queues = append(p.queues, queueProbe{name: name, length: length, capacity: capacity})
+}
+
+// Queues returns the current fill levels sorted by name.
+func (p *Pipeline) Queues() []Queue {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := make([]Queue, len(p.queues))
+	for i, q := range p.queues {
+		result[i] = Queue{Name: q.name, Len: q.length(), Cap: q.capacity}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
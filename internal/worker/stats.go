@@ -0,0 +1,252 @@
+package worker
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// Stats summarises one exchange/symbol over a rolling window.
+type Stats struct {
+	Exchange  string
+	Symbol    string
+	Window    time.Duration
+	Ticks     int
+	TickRate  float64 // ticks per second
+	First     float64
+	Last      float64
+	Return    float64 // simple return Last/First - 1
+	LogReturn float64
+	MeanLog   float64 // mean tick-to-tick log return
+	StdDevLog float64
+	// RealizedVol is sqrt(sum of squared log returns) over the window;
+	// AnnualizedVol scales it to a year.
+	RealizedVol   float64
+	AnnualizedVol float64
+	UpdatedAt     time.Time
+}
+
+type sample struct {
+	at    time.Time
+	price float64
+	ret   float64 // log return from the previous sample
+}
+
+// windowSums keeps running sums for one window so each tick costs O(windows)
+// instead of a rescan.
+type windowSums struct {
+	dur   time.Duration
+	head  int
+	sum   float64
+	sumSq float64
+}
+
+type series struct {
+	mu      sync.Mutex
+	samples []sample
+	windows []windowSums
+	maxDur  time.Duration
+	dead    bool // removed from the engine by sweep
+}
+
+func (s *series) add(at time.Time, price float64) {
+	var ret float64
+	if n := len(s.samples); n > 0 && s.samples[n-1].price > 0 {
+		ret = math.Log(price / s.samples[n-1].price)
+	}
+	s.samples = append(s.samples, sample{at: at, price: price, ret: ret})
+	for i := range s.windows {
+		s.windows[i].sum += ret
+		s.windows[i].sumSq += ret * ret
+	}
+	s.evict(at)
+}
+
+// evict moves every window head past samples older than the window and drops
+// samples no window needs anymore.
+func (s *series) evict(now time.Time) {
+	oldest := len(s.samples)
+	for i := range s.windows {
+		w := &s.windows[i]
+		cutoff := now.Add(-w.dur)
+		for w.head < len(s.samples) && s.samples[w.head].at.Before(cutoff) {
+			r := s.samples[w.head].ret
+			w.sum -= r
+			w.sumSq -= r * r
+			w.head++
+		}
+		if w.head == len(s.samples) {
+			// Nothing left to subtract rounding error from
+			w.sum, w.sumSq = 0, 0
+		}
+		if w.head < oldest {
+			oldest = w.head
+		}
+	}
+
+	// Compact once half the buffer is dead, and re-sum the windows while the
+	// copy is paid for anyway so rounding error cannot build up
+	if oldest > 0 && oldest >= len(s.samples)/2 {
+		s.samples = append(s.samples[:0], s.samples[oldest:]...)
+		for i := range s.windows {
+			w := &s.windows[i]
+			w.head -= oldest
+			w.sum, w.sumSq = 0, 0
+			for _, smp := range s.samples[w.head:] {
+				w.sum += smp.ret
+				w.sumSq += smp.ret * smp.ret
+			}
+		}
+	}
+}
+
+// StatsEngine maintains rolling return and volatility statistics per
+// exchange/symbol for a fixed set of windows.
+type StatsEngine struct {
+	mu        sync.RWMutex
+	windows   []time.Duration
+	series    map[string]*series
+	lastSweep time.Time
+}
+
+func NewStatsEngine(windows []time.Duration) *StatsEngine {
+	return &StatsEngine{
+		windows: windows,
+		series:  make(map[string]*series),
+	}
+}
+
+func (e *StatsEngine) Windows() []time.Duration {
+	return e.windows
+}
+
+func (e *StatsEngine) Add(update domain.PriceUpdate) {
+	if update.Price <= 0 {
+		return
+	}
+	key := update.Exchange + ":" + update.Symbol
+	e.sweep(update.ReceivedAt)
+
+	for {
+		e.mu.RLock()
+		s, ok := e.series[key]
+		e.mu.RUnlock()
+
+		if !ok {
+			e.mu.Lock()
+			if s, ok = e.series[key]; !ok {
+				s = &series{windows: make([]windowSums, len(e.windows))}
+				for i, d := range e.windows {
+					s.windows[i].dur = d
+				}
+				e.series[key] = s
+			}
+			e.mu.Unlock()
+		}
+
+		s.mu.Lock()
+		if s.dead {
+			// Swept between the lookup and the lock, look it up again
+			s.mu.Unlock()
+			continue
+		}
+		s.add(update.ReceivedAt, update.Price)
+		s.mu.Unlock()
+		return
+	}
+}
+
+// sweep frees the series of pairs that stopped ticking, once per longest
+// window.
+func (e *StatsEngine) sweep(now time.Time) {
+	var longest time.Duration
+	for _, d := range e.windows {
+		longest = max(longest, d)
+	}
+
+	e.mu.RLock()
+	due := now.Sub(e.lastSweep) >= longest
+	e.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if now.Sub(e.lastSweep) < longest {
+		return
+	}
+	e.lastSweep = now
+
+	for key, s := range e.series {
+		s.mu.Lock()
+		s.evict(now)
+		if len(s.samples) == 0 {
+			s.dead = true
+			delete(e.series, key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Snapshot returns the statistics for one of the configured windows. ok is
+// false when the window is not configured or there is no data.
+func (e *StatsEngine) Snapshot(exchange, symbol string, window time.Duration, now time.Time) (Stats, bool) {
+	idx := -1
+	for i, d := range e.windows {
+		if d == window {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return Stats{}, false
+	}
+
+	e.mu.RLock()
+	s, ok := e.series[exchange+":"+symbol]
+	e.mu.RUnlock()
+	if !ok {
+		return Stats{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evict(now)
+	w := s.windows[idx]
+	n := len(s.samples) - w.head
+	if n <= 0 {
+		return Stats{}, false
+	}
+
+	first, last := s.samples[w.head], s.samples[len(s.samples)-1]
+	st := Stats{
+		Exchange:  exchange,
+		Symbol:    symbol,
+		Window:    window,
+		Ticks:     n,
+		TickRate:  float64(n) / window.Seconds(),
+		First:     first.price,
+		Last:      last.price,
+		Return:    last.price/first.price - 1,
+		LogReturn: math.Log(last.price / first.price),
+		UpdatedAt: last.at,
+	}
+
+	// The oldest sample's return points outside the window
+	sum, sumSq, count := w.sum-first.ret, w.sumSq-first.ret*first.ret, n-1
+	if count > 0 {
+		st.MeanLog = sum / float64(count)
+		if variance := sumSq/float64(count) - st.MeanLog*st.MeanLog; variance > 0 {
+			st.StdDevLog = math.Sqrt(variance)
+		}
+		if sumSq > 0 {
+			st.RealizedVol = math.Sqrt(sumSq)
+			st.AnnualizedVol = st.RealizedVol * math.Sqrt(float64(365*24*time.Hour)/float64(window))
+		}
+	}
+
+	return st, true
+}
@@ -0,0 +1,248 @@
+package worker
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/feeds"
+)
+
+// ValidationConfig controls the bad-tick filter between fan-in and the
+// worker pools. Zero thresholds disable the corresponding check.
+type ValidationConfig struct {
+	Enabled bool
+	// Symbols is the whitelist; empty accepts any symbol.
+	Symbols []string
+	// Window is how many accepted prices per exchange/symbol form the rolling
+	// reference; statistical checks start after MinSamples of them.
+	Window     int
+	MinSamples int
+	// MaxDeviationPct rejects ticks further than this from the rolling median.
+	MaxDeviationPct float64
+	// MaxStdDevs rejects ticks more than K standard deviations from the median.
+	MaxStdDevs float64
+	// ConsensusPct rejects ticks further than this from the median of the
+	// other exchanges' latest prices (needs at least two fresh venues).
+	ConsensusPct float64
+	ConsensusAge time.Duration
+}
+
+type rollingWindow struct {
+	prices   []float64
+	next     int
+	rejected int // consecutive statistical rejections
+}
+
+func (w *rollingWindow) add(price float64) {
+	w.rejected = 0
+	if len(w.prices) < cap(w.prices) {
+		w.prices = append(w.prices, price)
+		return
+	}
+	w.prices[w.next] = price
+	w.next = (w.next + 1) % len(w.prices)
+}
+
+func (w *rollingWindow) reset() {
+	w.prices = w.prices[:0]
+	w.next = 0
+	w.rejected = 0
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func stdDev(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sq / float64(len(values)))
+}
+
+type validator struct {
+	cfg     ValidationConfig
+	symbols map[string]bool
+	windows map[string]*rollingWindow
+	latest  map[string]map[string]pricePoint
+	mode    *domain.Manager
+}
+
+type pricePoint struct {
+	price float64
+	at    time.Time
+}
+
+func newValidator(cfg ValidationConfig, mode *domain.Manager) *validator {
+	v := &validator{
+		windows: make(map[string]*rollingWindow),
+		latest:  make(map[string]map[string]pricePoint),
+		mode:    mode,
+	}
+	v.setConfig(cfg)
+	return v
+}
+
+// setConfig swaps the rules in place. The rolling windows are sized by
+// Window, so they restart empty when it changes.
+func (v *validator) setConfig(cfg ValidationConfig) {
+	if cfg.Window <= 0 {
+		cfg.Window = 50
+	}
+	if cfg.MinSamples <= 0 || cfg.MinSamples > cfg.Window {
+		cfg.MinSamples = min(10, cfg.Window)
+	}
+	if cfg.ConsensusAge <= 0 {
+		cfg.ConsensusAge = 5 * time.Second
+	}
+	if cfg.Window != v.cfg.Window {
+		v.windows = make(map[string]*rollingWindow)
+	}
+
+	v.symbols = nil
+	if len(cfg.Symbols) > 0 {
+		v.symbols = make(map[string]bool, len(cfg.Symbols))
+		for _, s := range cfg.Symbols {
+			v.symbols[s] = true
+		}
+	}
+	v.cfg = cfg
+}
+
+// check returns an empty string for a good tick or the rejection reason.
+func (v *validator) check(update domain.PriceUpdate) string {
+	if math.IsNaN(update.Price) || math.IsInf(update.Price, 0) {
+		return "price is not a finite number"
+	}
+	if update.Price <= 0 {
+		return "price is not positive"
+	}
+	if v.symbols != nil && !v.symbols[update.Symbol] {
+		return "unknown symbol"
+	}
+
+	// The test generators emit uniform noise, so only sanity checks apply there
+	if v.mode.GetMode() == domain.ModeTest {
+		return ""
+	}
+
+	key := update.Exchange + ":" + update.Symbol
+	w, ok := v.windows[key]
+	if !ok {
+		w = &rollingWindow{prices: make([]float64, 0, v.cfg.Window)}
+		v.windows[key] = w
+	}
+
+	if len(w.prices) >= v.cfg.MinSamples {
+		if reason := v.checkRolling(w, update.Price); reason != "" {
+			w.rejected++
+			// A long run of rejections is a level shift, not a bad tick
+			if w.rejected < v.cfg.MinSamples {
+				return reason
+			}
+			w.reset()
+		}
+	}
+
+	if v.cfg.ConsensusPct > 0 {
+		var others []float64
+		for ex, p := range v.latest[update.Symbol] {
+			if ex != update.Exchange && update.ReceivedAt.Sub(p.at) <= v.cfg.ConsensusAge {
+				others = append(others, p.price)
+			}
+		}
+		if len(others) >= 2 {
+			consensus := median(others)
+			if deviation := math.Abs(update.Price-consensus) / consensus * 100; deviation > v.cfg.ConsensusPct {
+				return fmt.Sprintf("%.2f%% from cross-exchange consensus %g", deviation, consensus)
+			}
+		}
+	}
+
+	return ""
+}
+
+func (v *validator) checkRolling(w *rollingWindow, price float64) string {
+	med := median(w.prices)
+	deviation := math.Abs(price-med) / med * 100
+	if v.cfg.MaxDeviationPct > 0 && deviation > v.cfg.MaxDeviationPct {
+		return fmt.Sprintf("%.2f%% from rolling median %g", deviation, med)
+	}
+	if sd := stdDev(w.prices); v.cfg.MaxStdDevs > 0 && sd > 0 {
+		if k := math.Abs(price-med) / sd; k > v.cfg.MaxStdDevs {
+			return fmt.Sprintf("%.1f standard deviations from rolling median %g", k, med)
+		}
+	}
+	return ""
+}
+
+func (v *validator) accept(update domain.PriceUpdate) {
+	if w, ok := v.windows[update.Exchange+":"+update.Symbol]; ok {
+		w.add(update.Price)
+	}
+
+	venues, ok := v.latest[update.Symbol]
+	if !ok {
+		venues = make(map[string]pricePoint)
+		v.latest[update.Symbol] = venues
+	}
+	venues[update.Exchange] = pricePoint{price: update.Price, at: update.ReceivedAt}
+}
+
+// runValidation forwards good ticks to out and rejected ones to quarantine.
+// A config stored in rules takes effect from the next tick.
+func runValidation(rules *atomic.Pointer[ValidationConfig], mode *domain.Manager, monitor *feeds.Monitor, in <-chan domain.PriceUpdate, out chan<- domain.PriceUpdate, quarantine chan<- domain.QuarantinedTick, logger *slog.Logger) {
+	cfg := rules.Load()
+	v := newValidator(*cfg, mode)
+
+	for update := range in {
+		monitor.Tick(update)
+
+		if next := rules.Load(); next != cfg {
+			cfg = next
+			v.setConfig(*cfg)
+		}
+		if !cfg.Enabled {
+			out <- update
+			continue
+		}
+
+		if reason := v.check(update); reason != "" {
+			logger.Debug("Tick rejected",
+				"exchange", update.Exchange,
+				"symbol", update.Symbol,
+				"price", update.Price,
+				"reason", reason)
+			monitor.Rejected(update)
+
+			select {
+			case quarantine <- domain.QuarantinedTick{Update: update, Reason: reason}:
+			default:
+				droppedTotal.With(update.Exchange, "quarantine").Inc()
+				logger.Warn("Quarantine channel full, dropping rejected tick")
+			}
+			continue
+		}
+
+		v.accept(update)
+		out <- update
+	}
+}
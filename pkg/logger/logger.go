@@ -8,7 +8,9 @@ import (
 	"time"
 )
 
-func SetupLogger() (*slog.Logger, func()) {
+// SetupLogger logs JSON to stdout and a timestamped file under logs/. Pass
+// a *slog.LevelVar to change the level while running.
+func SetupLogger(level slog.Leveler) (*slog.Logger, func()) {
 	absLogsDir, err := filepath.Abs("logs")
 	if err != nil {
 		panic("failed to get absolute path for logs directory: " + err.Error())
@@ -26,7 +28,7 @@ func SetupLogger() (*slog.Logger, func()) {
 
 	multiWriter := io.MultiWriter(os.Stdout, logFile)
 	logger := slog.New(slog.NewJSONHandler(multiWriter, &slog.HandlerOptions{
-		Level: slog.LevelInfo, // Changed from Debug to Info to reduce verbosity
+		Level: level,
 	}))
 
 	cleanup := func() {
@@ -0,0 +1,157 @@
+// Package metrics is a small dependency-free implementation of counters,
+// gauges and histograms exposed in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefBuckets suit latencies in seconds, from a fast Redis call to a slow
+// query.
+var DefBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// atomicFloat is a float64 updated with compare-and-swap.
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if f.bits.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// Counter only goes up.
+type Counter struct {
+	v atomicFloat
+}
+
+func (c *Counter) Inc() { c.v.add(1) }
+
+// Add ignores negative values, a counter never decreases.
+func (c *Counter) Add(v float64) {
+	if v > 0 {
+		c.v.add(v)
+	}
+}
+
+func (c *Counter) Value() float64 { return c.v.load() }
+
+// Gauge can go up and down.
+type Gauge struct {
+	v atomicFloat
+}
+
+func (g *Gauge) Set(v float64)  { g.v.set(v) }
+func (g *Gauge) Add(v float64)  { g.v.add(v) }
+func (g *Gauge) Inc()           { g.v.add(1) }
+func (g *Gauge) Dec()           { g.v.add(-1) }
+func (g *Gauge) Value() float64 { return g.v.load() }
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	upper []float64
+	// counts has one more slot than upper for observations above the last
+	// bucket; the +Inf bucket and _count are the total of all slots.
+	counts []atomic.Uint64
+	sum    atomicFloat
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{upper: buckets, counts: make([]atomic.Uint64, len(buckets)+1)}
+}
+
+func (h *Histogram) Observe(v float64) {
+	// Buckets are stored non-cumulative and summed on export
+	h.counts[sort.SearchFloat64s(h.upper, v)].Add(1)
+	h.sum.add(v)
+}
+
+// ObserveSince records the seconds elapsed since start.
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// family holds every labelled series of one metric.
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.RWMutex
+	series map[string]*series
+	fn     func() float64
+}
+
+type series struct {
+	values    []string
+	counter   *Counter
+	gauge     *Gauge
+	histogram *Histogram
+}
+
+func (f *family) with(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.series[key]; ok {
+		return s
+	}
+	s = &series{values: append([]string(nil), values...)}
+	switch f.kind {
+	case "counter":
+		s.counter = &Counter{}
+	case "gauge":
+		s.gauge = &Gauge{}
+	case "histogram":
+		s.histogram = newHistogram(f.buckets)
+	}
+	f.series[key] = s
+	return s
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct{ f *family }
+
+func (v *CounterVec) With(values ...string) *Counter { return v.f.with(values).counter }
+
+// GaugeVec is a gauge partitioned by labels.
+type GaugeVec struct{ f *family }
+
+func (v *GaugeVec) With(values ...string) *Gauge { return v.f.with(values).gauge }
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct{ f *family }
+
+func (v *HistogramVec) With(values ...string) *Histogram { return v.f.with(values).histogram }
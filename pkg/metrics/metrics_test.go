@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"bufio"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if err := r.WritePrometheus(&b); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	return b.String()
+}
+
+func TestCounterAndGauge(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("requests_total", "Requests served.", "method", "path")
+	c.With("GET", "/a").Inc()
+	c.With("GET", "/a").Add(2)
+	c.With("GET", "/a").Add(-5) // ignored
+	c.With("POST", `/"q"`).Inc()
+
+	g := r.NewGauge("queue_depth", "Items\nqueued.")
+	g.Set(10)
+	g.Dec()
+
+	r.NewGaugeFunc("answer", "Computed at scrape time.", func() float64 { return 42 })
+
+	want := `# HELP answer Computed at scrape time.
+# TYPE answer gauge
+answer 42
+# HELP queue_depth Items\nqueued.
+# TYPE queue_depth gauge
+queue_depth 9
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{method="GET",path="/a"} 3
+requests_total{method="POST",path="/\"q\""} 1
+`
+	if got := scrape(t, r); got != want {
+		t.Errorf("scrape mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1, 0.5})
+	for _, v := range []float64{0.05, 0.1, 0.3, 0.7, 2, 3} {
+		h.Observe(v)
+	}
+
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 2
+latency_seconds_bucket{le="0.5"} 3
+latency_seconds_bucket{le="1"} 4
+latency_seconds_bucket{le="+Inf"} 6
+latency_seconds_sum 6.15
+latency_seconds_count 6
+`
+	if got := scrape(t, r); got != want {
+		t.Errorf("scrape mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// A scrape racing Observe must still expose non-decreasing buckets ending in
+// +Inf == _count.
+func TestHistogramScrapeIsMonotonic(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("work_seconds", "Work.", []float64{0.1, 1})
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				h.Observe(float64(n%3) * 0.6)
+			}
+		}()
+	}
+
+	for i := 0; i < 200; i++ {
+		var prev, inf, count uint64
+		sc := bufio.NewScanner(strings.NewReader(scrape(t, r)))
+		for sc.Scan() {
+			line := sc.Text()
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "work_seconds_sum") {
+				continue
+			}
+			fields := strings.Fields(line)
+			n, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				t.Fatalf("parse %q: %v", line, err)
+			}
+			switch {
+			case strings.HasPrefix(line, "work_seconds_count"):
+				count = n
+			case strings.Contains(line, `le="+Inf"`):
+				inf = n
+				fallthrough
+			default:
+				if n < prev {
+					t.Fatalf("bucket decreased to %d after %d: %s", n, prev, line)
+				}
+				prev = n
+			}
+		}
+		if inf != count {
+			t.Fatalf("+Inf bucket %d differs from count %d", inf, count)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestDuplicateMetricPanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("dup_total", "First.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate name did not panic")
+		}
+	}()
+	r.NewGauge("dup_total", "Second.")
+}
+
+func TestWrongLabelCountPanics(t *testing.T) {
+	r := NewRegistry()
+	v := r.NewCounterVec("labelled_total", "Labelled.", "a", "b")
+	defer func() {
+		if recover() == nil {
+			t.Error("With with too few label values did not panic")
+		}
+	}()
+	v.With("only-one")
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("hits_total", "Hits.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "hits_total 1\n") {
+		t.Errorf("body missing counter:\n%s", rec.Body.String())
+	}
+}